@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// scrapeCoalescer lets concurrent requests for the same scrape share one in-flight collection
+type scrapeCoalescer struct {
+	calls map[string]*scrapeCall
+	mu    sync.Mutex
+}
+
+type scrapeCall struct {
+	done    chan struct{}
+	metrics []prometheus.Metric
+}
+
+func newScrapeCoalescer() *scrapeCoalescer {
+	return &scrapeCoalescer{
+		calls: make(map[string]*scrapeCall),
+	}
+}
+
+// do runs collect for the key, unless a collection for the key is already in flight.
+// In that case the result of the running collection is returned and shared is true.
+func (s *scrapeCoalescer) do(key string, collect func() []prometheus.Metric) (metrics []prometheus.Metric, shared bool) {
+	s.mu.Lock()
+	if call, found := s.calls[key]; found {
+		s.mu.Unlock()
+
+		<-call.done
+		return call.metrics, true
+	}
+
+	call := &scrapeCall{done: make(chan struct{})}
+	s.calls[key] = call
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.calls, key)
+		s.mu.Unlock()
+
+		close(call.done)
+	}()
+
+	call.metrics = collect()
+	return call.metrics, false
+}
+
+// detachedContext returns a context which is not canceled with ctx (e.g. when the client of the first request disconnects)
+// but keeps its deadline and values
+func detachedContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	detached := context.WithoutCancel(ctx)
+
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(detached, deadline)
+	}
+
+	return context.WithCancel(detached)
+}
+
+// collectMetrics collects all metrics of c into a slice
+func collectMetrics(c prometheus.Collector) []prometheus.Metric {
+	ch := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	metrics := make([]prometheus.Metric, 0)
+	go func() {
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+		close(done)
+	}()
+
+	c.Collect(ch)
+	close(ch)
+	<-done
+
+	return metrics
+}
+
+// metricsCollector serves metrics collected beforehand
+type metricsCollector struct {
+	metrics []prometheus.Metric
+}
+
+// Describe implements prometheus.Collector interface.
+// Metrics are not described as they are collected beforehand (unchecked collector).
+func (c *metricsCollector) Describe(ch chan<- *prometheus.Desc) {
+}
+
+// Collect implements prometheus.Collector interface
+func (c *metricsCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range c.metrics {
+		ch <- m
+	}
+}
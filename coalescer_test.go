@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrapeCoalescer(t *testing.T) {
+	s := newScrapeCoalescer()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	collect := func() []prometheus.Metric {
+		calls.Add(1)
+		<-release
+
+		return []prometheus.Metric{
+			prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 1, "router1"),
+		}
+	}
+
+	var sharedCount atomic.Int32
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			metrics, shared := s.do("router1|", collect)
+			assert.Len(t, metrics, 1)
+			if shared {
+				sharedCount.Add(1)
+			}
+		}()
+	}
+
+	// wait for all requests to join the in-flight collection
+	assert.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		return len(s.calls) == 1
+	}, time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load(), "concurrent requests should share one collection")
+	assert.Equal(t, int32(4), sharedCount.Load())
+
+	s.do("router1|", collect)
+	assert.Equal(t, int32(2), calls.Load(), "requests after the collection finished should collect again")
+}
+
+func TestDetachedContext(t *testing.T) {
+	deadline := time.Now().Add(time.Minute)
+	parent, cancelParent := context.WithDeadline(t.Context(), deadline)
+
+	ctx, cancel := detachedContext(parent)
+	defer cancel()
+
+	cancelParent()
+	assert.NoError(t, ctx.Err(), "detached context should not be canceled with the parent")
+
+	d, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.Equal(t, deadline, d, "deadline of the parent should be kept")
+}
@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/czerwonk/junos_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Reasons of collector errors
+const (
+	reasonTimeout            = "timeout"
+	reasonTransport          = "transport"
+	reasonParse              = "parse"
+	reasonDeviceRPCError     = "device-rpc-error"
+	reasonUnsupportedCommand = "unsupported-command"
+)
+
+var collectorErrorReasons = []string{
+	reasonTimeout,
+	reasonTransport,
+	reasonParse,
+	reasonDeviceRPCError,
+	reasonUnsupportedCommand,
+}
+
+var (
+	collectorSuccessDesc *prometheus.Desc
+	collectorErrorsDesc  *prometheus.Desc
+)
+
+func init() {
+	collectorSuccessDesc = prometheus.NewDesc(prefix+"collector_success", "Collector finished without error", []string{"target", "collector"}, nil)
+	collectorErrorsDesc = prometheus.NewDesc(prefix+"collector_errors_total", "Number of failed collector runs by reason (timeout, transport, parse, device-rpc-error, unsupported-command)", []string{"target", "collector", "reason"}, nil)
+}
+
+// collectorErrorReason classifies the error returned by a collector.
+// Commands skipped as not supported by the device are reported as unsupported-command on every scrape, so missing data can be explained.
+func collectorErrorReason(ctx context.Context, err error) string {
+	var netErr net.Error
+	if ctx.Err() != nil || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return reasonTimeout
+	}
+
+	var unsupportedErr *rpc.UnsupportedCommandError
+	if errors.As(err, &unsupportedErr) {
+		return reasonUnsupportedCommand
+	}
+
+	var rpcErr *rpc.RPCError
+	if errors.As(err, &rpcErr) {
+		return reasonDeviceRPCError
+	}
+
+	var transportErr *rpc.TransportError
+	if errors.As(err, &transportErr) {
+		return reasonTransport
+	}
+
+	// errors returned by the collectors themselves are caused by data they could not interpret
+	return reasonParse
+}
+
+type collectorErrorKey struct {
+	target    string
+	scope     string
+	collector string
+	reason    string
+}
+
+// collectorErrorCounter counts collector errors over the lifetime of the process (metrics are collected into a new registry on every scrape)
+type collectorErrorCounter struct {
+	counts map[collectorErrorKey]float64
+	mu     sync.Mutex
+}
+
+func newCollectorErrorCounter() *collectorErrorCounter {
+	return &collectorErrorCounter{
+		counts: make(map[collectorErrorKey]float64),
+	}
+}
+
+// inc counts an error of the collector run in the scope (logical system and routing instance)
+func (c *collectorErrorCounter) inc(target, scope, collector, reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counts[collectorErrorKey{target: target, scope: scope, collector: collector, reason: reason}]++
+}
+
+// collect sends the error counters of the collector run in the scope for all reasons
+func (c *collectorErrorCounter) collect(ch chan<- prometheus.Metric, target, scope, collector string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, reason := range collectorErrorReasons {
+		v := c.counts[collectorErrorKey{target: target, scope: scope, collector: collector, reason: reason}]
+		ch <- prometheus.MustNewConstMetric(collectorErrorsDesc, prometheus.CounterValue, v, target, collector, reason)
+	}
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/czerwonk/junos_exporter/pkg/rpc"
+)
+
+func TestCollectorErrorReason(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		err    error
+		reason string
+	}{
+		{
+			name:   "deadline",
+			ctx:    context.Background(),
+			err:    errors.Wrap(context.DeadlineExceeded, "command aborted"),
+			reason: reasonTimeout,
+		},
+		{
+			name:   "context done",
+			ctx:    canceled,
+			err:    io.EOF,
+			reason: reasonTimeout,
+		},
+		{
+			name:   "transport",
+			ctx:    context.Background(),
+			err:    errors.Wrap(&rpc.TransportError{Command: "show bgp summary", Err: io.EOF}, "failed to run command"),
+			reason: reasonTransport,
+		},
+		{
+			name:   "parse",
+			ctx:    context.Background(),
+			err:    &rpc.ParseError{Command: "show bgp summary", Err: io.EOF},
+			reason: reasonParse,
+		},
+		{
+			name:   "collector",
+			ctx:    context.Background(),
+			err:    errors.New("no NTP metrics parsed"),
+			reason: reasonParse,
+		},
+		{
+			name:   "rpc error",
+			ctx:    context.Background(),
+			err:    &rpc.RPCError{Message: "permission denied"},
+			reason: reasonDeviceRPCError,
+		},
+		{
+			name:   "unsupported",
+			ctx:    context.Background(),
+			err:    &rpc.UnsupportedCommandError{Command: "show chassis satellite", Err: &rpc.RPCError{Message: "syntax error, expecting <command>: satellite"}},
+			reason: reasonUnsupportedCommand,
+		},
+		{
+			name:   "skipped",
+			ctx:    context.Background(),
+			err:    errors.Wrap(&rpc.UnsupportedCommandError{Command: "show chassis satellite", Err: rpc.ErrCommandSkipped}, "failed to run command"),
+			reason: reasonUnsupportedCommand,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.reason, collectorErrorReason(test.ctx, test.err))
+		})
+	}
+}
+
+func TestCollectorErrorCounter(t *testing.T) {
+	c := newCollectorErrorCounter()
+	c.inc("router1", "", "BGP", reasonParse)
+	c.inc("router1", "", "BGP", reasonParse)
+	c.inc("router1", "", "BGP", reasonTimeout)
+	c.inc("router2", "", "BGP", reasonParse)
+	c.inc("router1", "ls1", "BGP", reasonParse)
+
+	col := &metricsCollector{metrics: collectMetricsFunc(func(ch chan<- prometheus.Metric) {
+		c.collect(ch, "router1", "", "BGP")
+	})}
+
+	expected := `
+# HELP junos_collector_errors_total Number of failed collector runs by reason (timeout, transport, parse, device-rpc-error, unsupported-command)
+# TYPE junos_collector_errors_total counter
+junos_collector_errors_total{collector="BGP",reason="device-rpc-error",target="router1"} 0
+junos_collector_errors_total{collector="BGP",reason="parse",target="router1"} 2
+junos_collector_errors_total{collector="BGP",reason="timeout",target="router1"} 1
+junos_collector_errors_total{collector="BGP",reason="transport",target="router1"} 0
+junos_collector_errors_total{collector="BGP",reason="unsupported-command",target="router1"} 0
+`
+	assert.NoError(t, testutil.CollectAndCompare(col, strings.NewReader(expected)))
+}
+
+func collectMetricsFunc(f func(ch chan<- prometheus.Metric)) []prometheus.Metric {
+	ch := make(chan prometheus.Metric, 10)
+	f(ch)
+	close(ch)
+
+	metrics := make([]prometheus.Metric, 0)
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+
+	return metrics
+}
@@ -3,169 +3,357 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"io"
+	"maps"
 	"regexp"
-
-	"github.com/czerwonk/junos_exporter/pkg/features/ddosprotection"
-	"github.com/czerwonk/junos_exporter/pkg/features/poe"
+	"slices"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/czerwonk/junos_exporter/internal/config"
 	"github.com/czerwonk/junos_exporter/pkg/collector"
 	"github.com/czerwonk/junos_exporter/pkg/connector"
-	"github.com/czerwonk/junos_exporter/pkg/features/accounting"
-	"github.com/czerwonk/junos_exporter/pkg/features/aaa"
-	"github.com/czerwonk/junos_exporter/pkg/features/alarm"
-	"github.com/czerwonk/junos_exporter/pkg/features/arp"
-	"github.com/czerwonk/junos_exporter/pkg/features/bfd"
-	"github.com/czerwonk/junos_exporter/pkg/features/bgp"
-	"github.com/czerwonk/junos_exporter/pkg/features/dot1x"
-	"github.com/czerwonk/junos_exporter/pkg/features/environment"
-	"github.com/czerwonk/junos_exporter/pkg/features/firewall"
-	"github.com/czerwonk/junos_exporter/pkg/features/fpc"
-	"github.com/czerwonk/junos_exporter/pkg/features/interfacediagnostics"
-	"github.com/czerwonk/junos_exporter/pkg/features/interfacequeue"
-	"github.com/czerwonk/junos_exporter/pkg/features/interfaces"
-	"github.com/czerwonk/junos_exporter/pkg/features/ipsec"
-	"github.com/czerwonk/junos_exporter/pkg/features/isis"
-	"github.com/czerwonk/junos_exporter/pkg/features/krt"
-	"github.com/czerwonk/junos_exporter/pkg/features/l2circuit"
-	"github.com/czerwonk/junos_exporter/pkg/features/l2vpn"
-	"github.com/czerwonk/junos_exporter/pkg/features/lacp"
-	"github.com/czerwonk/junos_exporter/pkg/features/ldp"
-	"github.com/czerwonk/junos_exporter/pkg/features/lldp"
-	"github.com/czerwonk/junos_exporter/pkg/features/mac"
-	"github.com/czerwonk/junos_exporter/pkg/features/macsec"
-	"github.com/czerwonk/junos_exporter/pkg/features/mplslsp"
-	"github.com/czerwonk/junos_exporter/pkg/features/nat"
-	"github.com/czerwonk/junos_exporter/pkg/features/nat2"
-	"github.com/czerwonk/junos_exporter/pkg/features/ntp"
-	"github.com/czerwonk/junos_exporter/pkg/features/ospf"
-	"github.com/czerwonk/junos_exporter/pkg/features/power"
-	"github.com/czerwonk/junos_exporter/pkg/features/route"
-	"github.com/czerwonk/junos_exporter/pkg/features/routingengine"
-	"github.com/czerwonk/junos_exporter/pkg/features/rpki"
-	"github.com/czerwonk/junos_exporter/pkg/features/rpm"
-	"github.com/czerwonk/junos_exporter/pkg/features/security"
-	"github.com/czerwonk/junos_exporter/pkg/features/securityike"
-	"github.com/czerwonk/junos_exporter/pkg/features/securitypolicies"
-	"github.com/czerwonk/junos_exporter/pkg/features/storage"
-	"github.com/czerwonk/junos_exporter/pkg/features/subscriber"
-	"github.com/czerwonk/junos_exporter/pkg/features/system"
-	"github.com/czerwonk/junos_exporter/pkg/features/systemstatistics"
-	"github.com/czerwonk/junos_exporter/pkg/features/twamp"
-	"github.com/czerwonk/junos_exporter/pkg/features/vpws"
-	"github.com/czerwonk/junos_exporter/pkg/features/vrrp"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/all"
+	"github.com/czerwonk/junos_exporter/pkg/features/custom"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
+	"github.com/czerwonk/junos_exporter/pkg/routinginstance"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
+// collectorKeys are the keys of all registered collectors (used in the cache config and the collect[] parameter)
+var collectorKeys = collector.Keys()
+
+// validateCollectorKeys returns an error if one of the keys is not the key of an available collector
+// (including the custom collectors defined in the config)
+func validateCollectorKeys(keys []string, cfg *config.Config) error {
+	valid := append(slices.Clone(collectorKeys), customCollectorKeys(cfg)...)
+
+	for _, k := range keys {
+		if !slices.Contains(valid, k) {
+			return fmt.Errorf("unknown collector %q (valid collectors: %s)", k, strings.Join(valid, ", "))
+		}
+	}
+
+	return nil
+}
+
+// customCollectorKeys returns the sorted keys of the custom collectors defined in the config
+func customCollectorKeys(cfg *config.Config) []string {
+	return slices.Sorted(maps.Keys(cfg.CustomCollectors))
+}
+
+// validateCustomCollectors returns an error if a custom collector uses the key of a built-in collector
+// or exports a metric with the name of a metric of a built-in collector or the exporter
+func validateCustomCollectors(cfg *config.Config) error {
+	keys := customCollectorKeys(cfg)
+	for _, k := range keys {
+		if slices.Contains(collectorKeys, k) {
+			return fmt.Errorf("custom collector %q conflicts with the built-in collector of the same name", k)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	for _, b := range builtInDescribers() {
+		// the built-in collectors are checked one by one, as metrics of different collectors may share a name
+		reg := prometheus.NewRegistry()
+		if reg.Register(b) != nil {
+			continue
+		}
+
+		for _, k := range keys {
+			c := describerFunc(custom.NewCollector(k, cfg.CustomCollectors[k]).Describe)
+			err := reg.Register(c)
+			if err != nil {
+				return fmt.Errorf("custom collector %q conflicts with a built-in metric: %w", k, err)
+			}
+
+			reg.Unregister(c)
+		}
+	}
+
+	return nil
+}
+
+// builtInDescribers returns collectors describing the metrics of the exporter and of each built-in collector
+func builtInDescribers() []prometheus.Collector {
+	describers := []prometheus.Collector{
+		describerFunc((&junosCollector{collectors: &collectors{}}).Describe),
+		describerFunc(func(ch chan<- *prometheus.Desc) {
+			ch <- snapshotAgeDesc
+			ch <- snapshotSuccessDesc
+		}),
+	}
+
+	for _, r := range collector.Registrations() {
+		describers = append(describers, describerFunc(r.Collector(collector.Options{}).Describe))
+	}
+
+	return describers
+}
+
+// clientFeatures are the features changing the commands run on a device instead of enabling a collector
+var clientFeatures = []struct {
+	feature     string
+	description string
+}{
+	{feature: "satellite", description: "metrics from satellite devices"},
+	{feature: "license", description: "license metrics"},
+}
+
+// registerFeatureFlags defines the flags enabling the features of the registered collectors and the client features
+// if no config file is used (by feature)
+func registerFeatureFlags() map[string]*bool {
+	flags := make(map[string]*bool)
+	for _, r := range collector.Registrations() {
+		flags[r.Feature] = flag.Bool(r.Flag, r.Default&collector.EnabledByFlag != 0, "Scrape "+r.Description)
+	}
+
+	for _, f := range clientFeatures {
+		flags[f.feature] = flag.Bool(f.feature+".enabled", false, "Scrape "+f.description)
+	}
+
+	return flags
+}
+
+// printCollectors writes a table of the registered collectors to w
+func printCollectors(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tFEATURE\tFLAG\tDEFAULT\tOPTIONS\tDESCRIPTION")
+
+	for _, r := range collector.Registrations() {
+		options := "-"
+		if len(r.Options) > 0 {
+			names := make([]string, 0, len(r.Options))
+			for _, o := range r.Options {
+				names = append(names, string(o))
+			}
+			options = strings.Join(names, ",")
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t-%s\t%s\t%s\t%s\n", r.Key, r.Feature, r.Flag, defaultDescription(r.Default), options, r.Description)
+	}
+
+	tw.Flush()
+}
+
+func defaultDescription(d collector.Default) string {
+	switch d {
+	case collector.Enabled:
+		return "enabled"
+	case collector.EnabledInConfig:
+		return "enabled (config file)"
+	case collector.EnabledByFlag:
+		return "enabled (flags)"
+	default:
+		return "disabled"
+	}
+}
+
+// logicalSystemKeys are the keys of the collectors supporting to run their commands in a logical system (logical-system <name>)
+var logicalSystemKeys = keysSupporting(collector.OptionLogicalSystem)
+
+// routingInstanceKeys are the keys of the collectors supporting to run their commands in a routing instance
+var routingInstanceKeys = keysSupporting(collector.OptionRoutingInstance)
+
+func keysSupporting(o collector.Option) []string {
+	keys := make([]string, 0)
+	for _, r := range collector.Registrations() {
+		if r.Supports(o) {
+			keys = append(keys, r.Key)
+		}
+	}
+
+	return keys
+}
+
 type collectors struct {
 	logicalSystem string
-	collectors    map[string]collector.RPCCollector
+	instance      string
+	module        *config.ModuleConfig
+	only          []string
+	collectors    map[string]map[string]collector.RPCCollector
+	keys          map[collector.RPCCollector]string
 	devices       map[string][]collector.RPCCollector
 	cfg           *config.Config
 }
 
-func collectorsForDevices(devices []*connector.Device, cfg *config.Config, logicalSystem string) *collectors {
+// collectorsForDevices initializes the collectors enabled for the devices.
+// If a module is passed, its features and options are used instead of the ones configured for the devices.
+// If keys are passed in only, collectors with other keys are skipped.
+func collectorsForDevices(devices []*connector.Device, cfg *config.Config, logicalSystem string, module *config.ModuleConfig, only []string) *collectors {
+	return newCollectors(devices, cfg, logicalSystem, "", module, only)
+}
+
+func newCollectors(devices []*connector.Device, cfg *config.Config, logicalSystem, instance string, module *config.ModuleConfig, only []string) *collectors {
 	c := &collectors{
 		logicalSystem: logicalSystem,
-		collectors:    make(map[string]collector.RPCCollector),
+		instance:      instance,
+		module:        module,
+		only:          only,
+		collectors:    make(map[string]map[string]collector.RPCCollector),
+		keys:          make(map[collector.RPCCollector]string),
 		devices:       make(map[string][]collector.RPCCollector),
 		cfg:           cfg,
 	}
 
 	for _, d := range devices {
-		c.initCollectorsForDevices(d, deviceInterfaceRegex(cfg, d.Host))
+		descRe := deviceInterfaceRegex(cfg, d.Host)
+		if module != nil && module.IfDescReg != nil {
+			descRe = module.IfDescReg
+		}
+
+		c.initCollectorsForDevices(d, descRe, deviceInterfaceFilter(cfg, d.Host), deviceRoutingInstanceFilter(cfg, d.Host))
 	}
 
 	return c
 }
 
-func (c *collectors) initCollectorsForDevices(device *connector.Device, descRe *regexp.Regexp) {
+func (c *collectors) initCollectorsForDevices(device *connector.Device, descRe *regexp.Regexp, ifFilter *interfacefilter.Filter, instances *routinginstance.Filter) {
 	f := c.cfg.FeaturesForDevice(device.Host)
+	filter := *alarmFilter
+	if c.module != nil {
+		f = &c.module.Features
+
+		if c.module.AlarmFilter != "" {
+			filter = c.module.AlarmFilter
+		}
+	}
 
 	c.devices[device.Host] = make([]collector.RPCCollector, 0)
 
-	c.addCollectorIfEnabledForDevice(device, "routingengine", f.RoutingEngine, routingengine.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "accounting", f.Accounting, accounting.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "aaa", f.AAA, aaa.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "alarm", f.Alarm, func() collector.RPCCollector {
-		return alarm.NewCollector(*alarmFilter)
-	})
-	c.addCollectorIfEnabledForDevice(device, "ntp", f.NTP, func() collector.RPCCollector {
-		return ntp.NewCollector()
-	})
-	c.addCollectorIfEnabledForDevice(device, "bfd", f.BFD, bfd.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "bgp", f.BGP, func() collector.RPCCollector {
-		return bgp.NewCollector(c.logicalSystem, descRe)
-	})
-	c.addCollectorIfEnabledForDevice(device, "dot1x", f.DOT1X, dot1x.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "env", f.Environment, environment.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "firewall", f.Firewall, firewall.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "fpc", f.FPC, fpc.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "ifacediag", f.InterfaceDiagnostic, func() collector.RPCCollector {
-		return interfacediagnostics.NewCollector(descRe)
-	})
-	c.addCollectorIfEnabledForDevice(device, "ifacequeue", f.InterfaceQueue, func() collector.RPCCollector {
-		return interfacequeue.NewCollector(descRe)
-	})
-	c.addCollectorIfEnabledForDevice(device, "iface", f.Interfaces, func() collector.RPCCollector {
-		return interfaces.NewCollector(descRe)
-	})
-	c.addCollectorIfEnabledForDevice(device, "ipsec", f.IPSec, ipsec.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "isis", f.ISIS, isis.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "l2c", f.L2Circuit, l2circuit.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "l2vpn", f.L2Vpn, l2vpn.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "lacp", f.LACP, lacp.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "ldp", f.LDP, ldp.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "lldp", f.LLDP, lldp.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "nat", f.NAT, nat.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "nat2", f.NAT2, nat2.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "ospf", f.OSPF, func() collector.RPCCollector {
-		return ospf.NewCollector(c.logicalSystem)
-	})
-	c.addCollectorIfEnabledForDevice(device, "routes", f.Routes, route.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "rpki", f.RPKI, rpki.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "rpm", f.RPM, rpm.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "security", f.Security, security.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "security_ike", f.SecurityIKE, securityike.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "security_policies", f.SecurityPolicies, securitypolicies.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "storage", f.Storage, storage.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "system", (f.System || f.License), system.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "power", f.Power, power.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "mac", f.MAC, mac.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "vrrp", f.VRRP, vrrp.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "vpws", f.VPWS, vpws.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "mpls_lsp", f.MPLSLSP, mplslsp.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "subscriber", f.Subscriber, subscriber.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "macsec", f.MACSec, macsec.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "arp", f.ARP, arp.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "poe", f.Poe, poe.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "ddosprotection", f.DDOSProtection, ddosprotection.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "krt", f.KRT, krt.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "twamp", f.TWAMP, twamp.NewCollector)
-	c.addCollectorIfEnabledForDevice(device, "system_statistics", f.SystemStatistics, systemstatistics.NewCollector)
-
-}
-
-func (c *collectors) addCollectorIfEnabledForDevice(device *connector.Device, key string, enabled bool, newCollector func() collector.RPCCollector) {
+	opts := collector.Options{
+		LogicalSystem:             c.logicalSystem,
+		RoutingInstance:           c.instance,
+		RoutingInstanceFilter:     instances,
+		InterfaceDescriptionRegex: descRe,
+		InterfaceFilter:           ifFilter,
+		AlarmFilter:               filter,
+	}
+
+	for _, r := range collector.Registrations() {
+		c.addCollectorIfEnabledForDevice(device, r.Key, optionsID(r, opts), r.EnabledFor(f), func() collector.RPCCollector {
+			return r.Collector(opts)
+		})
+	}
+
+	for _, key := range customCollectorKeys(c.cfg) {
+		enabled := c.module == nil || slices.Contains(c.module.CustomCollectors, key)
+		c.addCollectorIfEnabledForDevice(device, key, "", enabled, func() collector.RPCCollector {
+			return custom.NewCollector(key, c.cfg.CustomCollectors[key])
+		})
+	}
+}
+
+// addCollectorIfEnabledForDevice adds the collector to the device. Devices share one instance of the collector
+// as long as it is created with the same option values (identified by options).
+func (c *collectors) addCollectorIfEnabledForDevice(device *connector.Device, key, options string, enabled bool, newCollector func() collector.RPCCollector) {
 	if !enabled {
 		return
 	}
 
-	col, found := c.collectors[key]
+	if len(c.only) > 0 && !slices.Contains(c.only, key) {
+		return
+	}
+
+	if c.collectors[key] == nil {
+		c.collectors[key] = make(map[string]collector.RPCCollector)
+	}
+
+	col, found := c.collectors[key][options]
 	if !found {
 		col = newCollector()
-		c.collectors[key] = col
+		c.collectors[key][options] = col
+		c.keys[col] = key
 	}
 
 	c.devices[device.Host] = append(c.devices[device.Host], col)
 }
 
-func (c *collectors) allEnabledCollectors() []collector.RPCCollector {
-	collectors := make([]collector.RPCCollector, len(c.collectors))
+// optionsID identifies the values of the per-device options the collector is created with
+func optionsID(r *collector.Registration, opts collector.Options) string {
+	b := &strings.Builder{}
+
+	if r.Supports(collector.OptionInterfaceDescriptionRegex) && opts.InterfaceDescriptionRegex != nil {
+		fmt.Fprintf(b, "description_regex=%s;", opts.InterfaceDescriptionRegex)
+	}
+
+	if r.Supports(collector.OptionInterfaceFilter) && opts.InterfaceFilter != nil {
+		fmt.Fprintf(b, "interface_filter=%p;", opts.InterfaceFilter)
+	}
+
+	if r.Supports(collector.OptionRoutingInstanceFilter) && opts.RoutingInstanceFilter != nil {
+		fmt.Fprintf(b, "routing_instance_filter=%p;", opts.RoutingInstanceFilter)
+	}
 
-	i := 0
-	for _, collector := range c.collectors {
-		collectors[i] = collector
-		i++
+	return b.String()
+}
+
+// forLogicalSystem initializes the collectors of the device supporting logical systems to run in the logical system
+// (nil if none of them is selected)
+func (c *collectors) forLogicalSystem(device *connector.Device, logicalSystem string) *collectors {
+	keys := c.selectedKeys(logicalSystemKeys)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return newCollectors([]*connector.Device{device}, c.cfg, logicalSystem, "", c.module, keys)
+}
+
+// forRoutingInstance initializes the collectors of the device supporting routing instances to run in the routing instance
+// (nil if none of them is selected)
+func (c *collectors) forRoutingInstance(device *connector.Device, instance string) *collectors {
+	keys := c.selectedKeys(routingInstanceKeys)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return newCollectors([]*connector.Device{device}, c.cfg, c.logicalSystem, instance, c.module, keys)
+}
+
+// selectedKeys returns the keys selected by the collect[] parameter
+func (c *collectors) selectedKeys(keys []string) []string {
+	selected := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if len(c.only) == 0 || slices.Contains(c.only, k) {
+			selected = append(selected, k)
+		}
+	}
+
+	return selected
+}
+
+// scope returns the logical system and routing instance the collectors run in (used to separate error counters)
+func (c *collectors) scope() string {
+	return c.logicalSystem + "/" + c.instance
+}
+
+// scopeSuffix returns the logical system and routing instance the collectors run in for log messages
+func (c *collectors) scopeSuffix() string {
+	parts := make([]string, 0, 2)
+	if c.logicalSystem != "" {
+		parts = append(parts, "logical system "+c.logicalSystem)
+	}
+
+	if c.instance != "" {
+		parts = append(parts, "routing instance "+c.instance)
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+func (c *collectors) allEnabledCollectors() []collector.RPCCollector {
+	collectors := make([]collector.RPCCollector, 0, len(c.keys))
+	for collector := range c.keys {
+		collectors = append(collectors, collector)
 	}
 
 	return collectors
@@ -179,3 +367,8 @@ func (c *collectors) collectorsForDevice(device *connector.Device) []collector.R
 
 	return cols
 }
+
+// keyOf returns the key the collector is registered with (e.g. "ifacediag")
+func (c *collectors) keyOf(col collector.RPCCollector) string {
+	return c.keys[col]
+}
@@ -3,12 +3,18 @@
 package main
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/czerwonk/junos_exporter/internal/config"
+	"github.com/czerwonk/junos_exporter/pkg/collector"
 	"github.com/czerwonk/junos_exporter/pkg/connector"
+	"github.com/czerwonk/junos_exporter/pkg/features/custom"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
+	"github.com/czerwonk/junos_exporter/pkg/routinginstance"
 )
 
 func TestCollectorsRegistered(t *testing.T) {
@@ -40,7 +46,7 @@ func TestCollectorsRegistered(t *testing.T) {
 
 	cols := collectorsForDevices([]*connector.Device{{
 		Host: "::1",
-	}}, c, "")
+	}}, c, "", nil, nil)
 
 	assert.Equal(t, 21, len(cols.collectors), "collector count")
 }
@@ -89,7 +95,7 @@ func TestCollectorsForDevices(t *testing.T) {
 	d2 := &connector.Device{
 		Host: "2001:678:1e0::2",
 	}
-	cols := collectorsForDevices([]*connector.Device{d1, d2}, c, "")
+	cols := collectorsForDevices([]*connector.Device{d1, d2}, c, "", nil, nil)
 
 	assert.Equal(t, 21, len(cols.collectorsForDevice(d1)), "device 1 collector count")
 
@@ -97,3 +103,270 @@ func TestCollectorsForDevices(t *testing.T) {
 	assert.Equal(t, 1, len(cd2), "device 2 collector count")
 	assert.Equal(t, "Interfaces", cd2[0].Name(), "device 2 collector name")
 }
+
+func TestCollectorsForDevicesWithModule(t *testing.T) {
+	c := &config.Config{
+		Features: config.FeatureConfig{
+			Alarm:      true,
+			BGP:        true,
+			Interfaces: true,
+		},
+		Devices: []*config.DeviceConfig{
+			{
+				Host: "2001:678:1e0::1",
+			},
+		},
+	}
+
+	d := &connector.Device{
+		Host: "2001:678:1e0::1",
+	}
+	m := &config.ModuleConfig{
+		Features: config.FeatureConfig{
+			BGP:    true,
+			Routes: true,
+		},
+	}
+	cols := collectorsForDevices([]*connector.Device{d}, c, "", m, nil)
+
+	cd := cols.collectorsForDevice(d)
+	assert.Equal(t, 2, len(cd), "collector count")
+}
+
+func TestCollectorKeys(t *testing.T) {
+	c := &config.Config{}
+	f := reflect.ValueOf(&c.Features).Elem()
+	for i := 0; i < f.NumField(); i++ {
+		if f.Field(i).Kind() == reflect.Bool {
+			f.Field(i).SetBool(true)
+		}
+	}
+
+	cols := collectorsForDevices([]*connector.Device{{
+		Host: "::1",
+	}}, c, "", nil, nil)
+
+	keys := make([]string, 0, len(cols.collectors))
+	for k := range cols.collectors {
+		keys = append(keys, k)
+	}
+
+	assert.ElementsMatch(t, collectorKeys, keys, "all collectors should be listed in collectorKeys")
+}
+
+func TestCollectorsForDevicesWithSelection(t *testing.T) {
+	c := &config.Config{
+		Features: config.FeatureConfig{
+			Alarm:      true,
+			BGP:        true,
+			Interfaces: true,
+		},
+	}
+
+	d := &connector.Device{
+		Host: "2001:678:1e0::1",
+	}
+	cols := collectorsForDevices([]*connector.Device{d}, c, "", nil, []string{"bgp", "alarm", "routes"})
+
+	cd := cols.collectorsForDevice(d)
+	assert.Equal(t, 2, len(cd), "collector count")
+	for _, col := range cd {
+		assert.Contains(t, []string{"bgp", "alarm"}, cols.keyOf(col))
+	}
+}
+
+func TestValidateCollectorKeys(t *testing.T) {
+	c := &config.Config{
+		CustomCollectors: map[string]*custom.Definition{
+			"krt_queue": {},
+		},
+	}
+
+	assert.NoError(t, validateCollectorKeys([]string{"bgp", "ifacediag", "routes"}, c))
+	assert.NoError(t, validateCollectorKeys([]string{"bgp", "krt_queue"}, c))
+	assert.ErrorContains(t, validateCollectorKeys([]string{"bgp", "foo"}, c), `unknown collector "foo" (valid collectors: aaa, accounting,`)
+}
+
+func TestValidateCustomCollectors(t *testing.T) {
+	assert.NoError(t, validateCustomCollectors(&config.Config{
+		CustomCollectors: map[string]*custom.Definition{"krt_queue": {}},
+	}))
+	assert.ErrorContains(t, validateCustomCollectors(&config.Config{
+		CustomCollectors: map[string]*custom.Definition{"krt": {}},
+	}), `custom collector "krt" conflicts with the built-in collector of the same name`)
+
+	for _, name := range []string{"up", "bgp_session_up"} {
+		def := &custom.Definition{Command: "show foo", Metrics: []*custom.MetricDefinition{{Name: name, Path: "foo"}}}
+		if err := def.Load(nil); err != nil {
+			t.Fatal(err)
+		}
+
+		assert.ErrorContains(t, validateCustomCollectors(&config.Config{
+			CustomCollectors: map[string]*custom.Definition{"foo": def},
+		}), `custom collector "foo" conflicts with a built-in metric`, name)
+	}
+}
+
+func TestCustomCollectors(t *testing.T) {
+	c := &config.Config{
+		Features: config.FeatureConfig{
+			BGP: true,
+		},
+		CustomCollectors: map[string]*custom.Definition{
+			"chassis_cluster": {},
+			"krt_queue":       {},
+		},
+	}
+
+	d := &connector.Device{
+		Host: "router1",
+	}
+
+	cols := collectorsForDevices([]*connector.Device{d}, c, "", nil, nil)
+	keys := make([]string, 0)
+	for _, col := range cols.collectorsForDevice(d) {
+		keys = append(keys, cols.keyOf(col))
+	}
+	assert.Equal(t, []string{"bgp", "chassis_cluster", "krt_queue"}, keys)
+
+	cols = collectorsForDevices([]*connector.Device{d}, c, "", &config.ModuleConfig{CustomCollectors: []string{"krt_queue"}}, nil)
+	keys = make([]string, 0)
+	for _, col := range cols.collectorsForDevice(d) {
+		keys = append(keys, cols.keyOf(col))
+	}
+	assert.Equal(t, []string{"krt_queue"}, keys, "only custom collectors listed in the module")
+}
+
+func TestCollectorsForLogicalSystem(t *testing.T) {
+	c := &config.Config{
+		Features: config.FeatureConfig{
+			BGP:        true,
+			ISIS:       true,
+			Interfaces: true,
+		},
+	}
+
+	d := &connector.Device{
+		Host: "2001:678:1e0::1",
+	}
+	cols := collectorsForDevices([]*connector.Device{d}, c, "", nil, nil)
+
+	ls := cols.forLogicalSystem(d, "LS1")
+	assert.Equal(t, "LS1", ls.logicalSystem)
+
+	names := make([]string, 0)
+	for _, col := range ls.collectorsForDevice(d) {
+		names = append(names, col.Name())
+	}
+	assert.ElementsMatch(t, []string{"BGP", "ISIS"}, names)
+
+	cols = collectorsForDevices([]*connector.Device{d}, c, "", nil, []string{"iface"})
+	assert.Nil(t, cols.forLogicalSystem(d, "LS1"))
+}
+
+func TestCollectorsForRoutingInstance(t *testing.T) {
+	c := &config.Config{
+		Features: config.FeatureConfig{
+			BGP:  true,
+			OSPF: true,
+			ISIS: true,
+		},
+	}
+
+	d := &connector.Device{
+		Host: "2001:678:1e0::1",
+	}
+	cols := collectorsForDevices([]*connector.Device{d}, c, "LS1", nil, nil)
+
+	ri := cols.forRoutingInstance(d, "CUST-A")
+	assert.Equal(t, "LS1", ri.logicalSystem)
+	assert.Equal(t, "CUST-A", ri.instance)
+	assert.Equal(t, " (logical system LS1, routing instance CUST-A)", ri.scopeSuffix())
+
+	names := make([]string, 0)
+	for _, col := range ri.collectorsForDevice(d) {
+		names = append(names, col.Name())
+	}
+	assert.ElementsMatch(t, []string{"OSPF", "ISIS"}, names)
+}
+
+func TestCollectorKeysSupportingOptions(t *testing.T) {
+	assert.Equal(t, []string{"arp", "bfd", "bgp", "firewall", "isis", "l2c", "ldp", "mpls_lsp", "ospf", "routes"}, logicalSystemKeys)
+	assert.Equal(t, []string{"arp", "isis", "ldp", "ospf"}, routingInstanceKeys)
+}
+
+func TestPrintCollectors(t *testing.T) {
+	b := &strings.Builder{}
+	printCollectors(b)
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	assert.Len(t, lines, len(collectorKeys)+1, "header and one line per collector")
+	assert.Regexp(t, `^KEY\s+FEATURE\s+FLAG\s+DEFAULT\s+OPTIONS\s+DESCRIPTION$`, lines[0])
+	assert.Contains(t, b.String(), "-ifdiag.enabled")
+	assert.Regexp(t, `(?m)^alarm\s+alarm\s+-alarm.enabled\s+enabled \(config file\)\s+alarm_filter\s+Alarm metrics$`, b.String())
+}
+
+func TestCollectorsWithDeviceOptions(t *testing.T) {
+	f1, err := interfacefilter.New([]string{"^xe-"}, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &config.Config{
+		Features: config.FeatureConfig{
+			Interfaces: true,
+			Alarm:      true,
+		},
+		Devices: []*config.DeviceConfig{
+			{Host: "router1", InterfaceFilter: &config.InterfaceFilterConfig{Filter: f1}},
+			{Host: "router2"},
+			{Host: "router3"},
+		},
+	}
+
+	r1 := &connector.Device{Host: "router1"}
+	r2 := &connector.Device{Host: "router2"}
+	r3 := &connector.Device{Host: "router3"}
+	cols := collectorsForDevices([]*connector.Device{r1, r2, r3}, c, "", nil, nil)
+
+	byKey := func(d *connector.Device) map[string]collector.RPCCollector {
+		m := make(map[string]collector.RPCCollector)
+		for _, col := range cols.collectorsForDevice(d) {
+			m[cols.keyOf(col)] = col
+		}
+		return m
+	}
+
+	c1, c2, c3 := byKey(r1), byKey(r2), byKey(r3)
+	assert.NotSame(t, c1["iface"], c2["iface"], "devices with different interface filters")
+	assert.Same(t, c2["iface"], c3["iface"], "devices with the same options share the collector")
+	assert.Same(t, c1["alarm"], c2["alarm"], "collectors without per-device options are shared")
+	assert.Len(t, cols.allEnabledCollectors(), 3)
+}
+
+func TestCollectorsWithDeviceRoutingInstanceFilter(t *testing.T) {
+	f1, err := routinginstance.New([]string{"^CUST-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &config.Config{
+		Features: config.FeatureConfig{
+			BGP: true,
+		},
+		Devices: []*config.DeviceConfig{
+			{Host: "router1", RoutingInstances: &config.RoutingInstanceConfig{Filter: f1}},
+			{Host: "router2"},
+		},
+	}
+
+	r1 := &connector.Device{Host: "router1"}
+	r2 := &connector.Device{Host: "router2"}
+	cols := collectorsForDevices([]*connector.Device{r1, r2}, c, "", nil, nil)
+
+	c1 := cols.collectorsForDevice(r1)
+	c2 := cols.collectorsForDevice(r2)
+	if assert.Len(t, c1, 1) && assert.Len(t, c2, 1) {
+		assert.NotSame(t, c1[0], c2[0], "devices with different routing instance filters")
+	}
+}
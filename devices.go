@@ -11,6 +11,7 @@ import (
 	"github.com/czerwonk/junos_exporter/internal/config"
 	"github.com/czerwonk/junos_exporter/pkg/connector"
 	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
 )
 
 func devicesForConfig(cfg *config.Config) ([]*connector.Device, error) {
@@ -68,18 +69,126 @@ func deviceFromDeviceConfig(device *config.DeviceConfig, hostname string, cfg *c
 		device.IfDescReg = re
 	}
 
+	hostKeyCallback, err := hostKeyCallbackForDevice(device, cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not initialize host key verification for device %s", device.Host)
+	}
+
+	jumpHosts, err := jumpHostsForDevice(device, cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not initialize jump hosts for device %s", device.Host)
+	}
+
+	dialer, err := dialerForDevice(device, cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not initialize proxy for device %s", device.Host)
+	}
+
 	return &connector.Device{
-		Host: hostname,
-		Auth: auth,
+		Host:              hostname,
+		Auth:              auth,
+		HostKeyCallback:   hostKeyCallback,
+		Dialer:            dialer,
+		JumpHosts:         jumpHosts,
+		Port:              device.Port,
+		ConnectTimeout:    device.ConnectTimeout,
+		KeepAliveInterval: device.KeepAliveInterval,
+		KeepAliveTimeout:  device.KeepAliveTimeout,
+		ExpireTimeout:     device.ExpireTimeout,
 	}, nil
 }
 
+func dialerForDevice(device *config.DeviceConfig, cfg *config.Config) (connector.Dialer, error) {
+	proxyURL := device.Proxy
+	if proxyURL == "" {
+		proxyURL = cfg.Proxy
+	}
+
+	if proxyURL == "" {
+		proxyURL = *sshProxy
+	}
+
+	if proxyURL == config.ProxyDirect {
+		return nil, nil
+	}
+
+	if proxyURL != "" {
+		return connector.ProxyDialer(proxyURL)
+	}
+
+	return connector.ProxyDialerFromEnvironment()
+}
+
+func jumpHostsForDevice(device *config.DeviceConfig, cfg *config.Config) ([]*connector.Device, error) {
+	if len(device.JumpHosts) == 0 {
+		return nil, nil
+	}
+
+	jumpHosts := make([]*connector.Device, len(device.JumpHosts))
+	for i, name := range device.JumpHosts {
+		j := cfg.FindJumpHost(name)
+		if j == nil {
+			return nil, fmt.Errorf("jump host %s is not defined", name)
+		}
+
+		dc := j.DeviceConfig()
+
+		auth, err := authForDevice(dc, cfg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not initialize auth for jump host %s", name)
+		}
+
+		hostKeyCallback, err := hostKeyCallbackForDevice(dc, cfg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not initialize host key verification for jump host %s", name)
+		}
+
+		dialer, err := dialerForDevice(dc, cfg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not initialize proxy for jump host %s", name)
+		}
+
+		jumpHosts[i] = &connector.Device{
+			Host:            j.Host,
+			Auth:            auth,
+			HostKeyCallback: hostKeyCallback,
+			Dialer:          dialer,
+		}
+	}
+
+	return jumpHosts, nil
+}
+
 func authForDevice(device *config.DeviceConfig, cfg *config.Config) (connector.AuthMethod, error) {
 	user := *sshUsername
 	if device.Username != "" {
 		user = device.Username
 	}
 
+	methods := device.AuthMethods
+	if len(methods) == 0 {
+		methods = cfg.AuthMethods
+	}
+
+	if len(methods) == 0 {
+		return defaultAuthForDevice(user, device, cfg)
+	}
+
+	auths := make([]connector.AuthMethod, len(methods))
+	for i, m := range methods {
+		auth, err := authMethodForDevice(m, user, device, cfg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "authentication method %s", m)
+		}
+
+		auths[i] = auth
+	}
+
+	return connector.ChainAuth(auths...), nil
+}
+
+// defaultAuthForDevice uses the first authentication method configured (key file, password)
+func defaultAuthForDevice(user string, device *config.DeviceConfig, cfg *config.Config) (connector.AuthMethod, error) {
 	if device.KeyFile != "" {
 		return authForKeyFile(user, device.KeyFile, device.KeyPassphrase)
 	}
@@ -103,6 +212,102 @@ func authForDevice(device *config.DeviceConfig, cfg *config.Config) (connector.A
 	return nil, errors.New("no valid authentication method available")
 }
 
+func authMethodForDevice(method, user string, device *config.DeviceConfig, cfg *config.Config) (connector.AuthMethod, error) {
+	switch method {
+	case config.AuthMethodKey:
+		keyFile, passphrase := keyFileForDevice(device)
+		if keyFile == "" {
+			return nil, errors.New("no key file configured")
+		}
+
+		return authForKeyFile(user, keyFile, passphrase)
+	case config.AuthMethodCertificate:
+		keyFile, passphrase := keyFileForDevice(device)
+		if keyFile == "" {
+			return nil, errors.New("no key file configured")
+		}
+
+		return authForCertFile(user, keyFile, certFileForDevice(device, keyFile), passphrase)
+	case config.AuthMethodAgent:
+		socket := os.Getenv("SSH_AUTH_SOCK")
+		if socket == "" {
+			return nil, errors.New("SSH_AUTH_SOCK is not set")
+		}
+
+		return connector.AuthByAgent(user, socket), nil
+	case config.AuthMethodPassword:
+		password := passwordForDevice(device, cfg)
+		if password == "" {
+			return nil, errors.New("no password configured")
+		}
+
+		return connector.AuthByPassword(user, password), nil
+	case config.AuthMethodKeyboardInteractive:
+		password := passwordForDevice(device, cfg)
+		if password == "" {
+			return nil, errors.New("no password configured")
+		}
+
+		return connector.AuthByKeyboardInteractive(user, password), nil
+	default:
+		return nil, errors.New("unknown authentication method")
+	}
+}
+
+func keyFileForDevice(device *config.DeviceConfig) (string, string) {
+	if device.KeyFile != "" {
+		return device.KeyFile, device.KeyPassphrase
+	}
+
+	return *sshKeyFile, *sshKeyPassphrase
+}
+
+func certFileForDevice(device *config.DeviceConfig, keyFile string) string {
+	if device.CertFile != "" {
+		return device.CertFile
+	}
+
+	if *sshCertFile != "" {
+		return *sshCertFile
+	}
+
+	return keyFile + "-cert.pub"
+}
+
+func passwordForDevice(device *config.DeviceConfig, cfg *config.Config) string {
+	if device.Password != "" {
+		return device.Password
+	}
+
+	if cfg.Password != "" {
+		return cfg.Password
+	}
+
+	return *sshPassword
+}
+
+func hostKeyCallbackForDevice(device *config.DeviceConfig, cfg *config.Config) (ssh.HostKeyCallback, error) {
+	if len(device.HostKeyFingerprints) > 0 {
+		return connector.VerifyHostKeyByFingerprints(device.HostKeyFingerprints...), nil
+	}
+
+	tofu := cfg.HostKeyTOFU || *sshHostKeyTOFU
+
+	if device.KnownHostsFile != "" {
+		return connector.VerifyHostKeyByKnownHosts(device.KnownHostsFile, tofu)
+	}
+
+	if cfg.KnownHostsFile != "" {
+		return connector.VerifyHostKeyByKnownHosts(cfg.KnownHostsFile, tofu)
+	}
+
+	if *sshKnownHostsFile != "" {
+		return connector.VerifyHostKeyByKnownHosts(*sshKnownHostsFile, tofu)
+	}
+
+	return nil, nil
+}
+
 func authForKeyFile(username, keyFile, keyPassphrase string) (connector.AuthMethod, error) {
 	f, err := os.Open(keyFile)
 	if err != nil {
@@ -117,3 +322,12 @@ func authForKeyFile(username, keyFile, keyPassphrase string) (connector.AuthMeth
 
 	return auth, nil
 }
+
+func authForCertFile(username, keyFile, certFile, keyPassphrase string) (connector.AuthMethod, error) {
+	auth, err := connector.AuthByCertificate(username, keyFile, certFile, keyPassphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load ssh certificate")
+	}
+
+	return auth, nil
+}
@@ -5,11 +5,42 @@ package config
 import (
 	"fmt"
 	"io"
+	"maps"
+	"reflect"
 	"regexp"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/features/custom"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
+	"github.com/czerwonk/junos_exporter/pkg/metricprofile"
+	"github.com/czerwonk/junos_exporter/pkg/routinginstance"
 	"gopkg.in/yaml.v2"
 )
 
+var labelNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+const (
+	// TransportCLI runs commands in the CLI and requests XML output using "| display xml"
+	TransportCLI = "cli"
+
+	// TransportNetconf sends commands as RPCs using the NETCONF subsystem
+	TransportNetconf = "netconf"
+)
+
+// ProxyDirect disables the use of proxies (including proxies configured by environment variables)
+const ProxyDirect = "direct"
+
+const (
+	AuthMethodKey                 = "key"
+	AuthMethodCertificate         = "certificate"
+	AuthMethodAgent               = "agent"
+	AuthMethodPassword            = "password"
+	AuthMethodKeyboardInteractive = "keyboard-interactive"
+)
+
 // Config represents the configuration for the exporter
 type Config struct {
 	Password    string          `yaml:"password"`
@@ -19,9 +50,127 @@ type Config struct {
 	LSEnabled   bool            `yaml:"logical_systems,omitempty"`
 	IfDescReStr string          `yaml:"interface_description_regex,omitempty"`
 	IfDescReg   *regexp.Regexp  `yaml:"-"`
+
+	KnownHostsFile string `yaml:"known_hosts_file,omitempty"`
+	HostKeyTOFU    bool   `yaml:"host_key_trust_on_first_use,omitempty"`
+
+	AuthMethods []string `yaml:"auth_methods,omitempty"`
+
+	JumpHosts []*JumpHostConfig `yaml:"jump_hosts,omitempty"`
+
+	// Proxy is the URL of the proxy to connect to devices through (socks5://, socks5h:// or http://)
+	Proxy string `yaml:"proxy,omitempty"`
+
+	// Concurrency is the number of collectors running concurrently on a device (each in its own SSH or NETCONF session)
+	Concurrency int `yaml:"concurrency,omitempty"`
+
+	// Cache configures which command responses are cached and for how long
+	Cache CacheConfig `yaml:"cache,omitempty"`
+
+	// PollingInterval enables polling of devices in the background (metrics are served from the last poll)
+	PollingInterval time.Duration `yaml:"polling_interval,omitempty"`
+
+	// Modules are named feature sets which can be selected per request (module parameter)
+	Modules map[string]*ModuleConfig `yaml:"modules,omitempty"`
+
+	// MetricsProfile is the naming and typing of the exported metrics (v1 or v2)
+	MetricsProfile string `yaml:"metrics_profile,omitempty"`
+
+	// InterfaceFilter selects the interfaces metrics are exported for
+	InterfaceFilter *InterfaceFilterConfig `yaml:"interface_filter,omitempty"`
+
+	// Labels are added to all metrics of all devices
+	Labels map[string]string `yaml:"labels,omitempty"`
+
+	// RoutingInstances enables the routing instance awareness of protocol collectors
+	RoutingInstances *RoutingInstanceConfig `yaml:"routing_instances,omitempty"`
+
+	// Groups are named templates of device settings which devices can reference (host, host_pattern and groups can not be set in groups)
+	Groups map[string]*DeviceConfig `yaml:"groups,omitempty"`
+
+	// CustomCollectors are collectors defined by command and paths to the values (by collector key)
+	CustomCollectors map[string]*custom.Definition `yaml:"custom_collectors,omitempty"`
+}
+
+// InterfaceFilterConfig selects interfaces by name using include and exclude regular expressions
+type InterfaceFilterConfig struct {
+	Include          []string                `yaml:"include,omitempty"`
+	Exclude          []string                `yaml:"exclude,omitempty"`
+	SkipLogicalUnits bool                    `yaml:"skip_logical_units,omitempty"`
+	Filter           *interfacefilter.Filter `yaml:"-"`
+}
+
+func (f *InterfaceFilterConfig) load() error {
+	filter, err := interfacefilter.New(f.Include, f.Exclude, f.SkipLogicalUnits)
+	if err != nil {
+		return err
+	}
+
+	f.Filter = filter
+	return nil
+}
+
+// RoutingInstanceConfig configures the routing instance awareness of protocol collectors
+type RoutingInstanceConfig struct {
+	// FanOut runs the collectors supporting routing instances once per routing instance
+	FanOut bool `yaml:"fan_out,omitempty"`
+
+	// Instances are the routing instances to fan out to (discovered on the device if not set)
+	Instances []string `yaml:"instances,omitempty"`
+
+	// Include is the allow-list of routing instances (regular expressions) to export metrics for
+	Include []string                `yaml:"include,omitempty"`
+	Filter  *routinginstance.Filter `yaml:"-"`
+}
+
+func (r *RoutingInstanceConfig) load() error {
+	filter, err := routinginstance.New(r.Include)
+	if err != nil {
+		return err
+	}
+
+	r.Filter = filter
+	return nil
+}
+
+// ModuleConfig is a named set of collectors (and their options) to scrape
+type ModuleConfig struct {
+	Features     FeatureConfig  `yaml:"features"`
+	AlarmFilter  string         `yaml:"alarm_filter,omitempty"`
+	IfDescRegStr string         `yaml:"interface_description_regex,omitempty"`
+	IfDescReg    *regexp.Regexp `yaml:"-"`
+
+	// CustomCollectors are the keys of the custom collectors to scrape (custom collectors are not part of modules by default)
+	CustomCollectors []string `yaml:"custom_collectors,omitempty"`
+}
+
+// CacheConfig defines the TTLs of cached command responses by collector key (e.g. "ifacediag") or command
+type CacheConfig struct {
+	Collectors map[string]time.Duration `yaml:"collectors,omitempty"`
+	Commands   map[string]time.Duration `yaml:"commands,omitempty"`
+}
+
+// Enabled returns if any responses are cached
+func (c *CacheConfig) Enabled() bool {
+	return len(c.Collectors) > 0 || len(c.Commands) > 0
+}
+
+// TTL returns the duration to cache the response of cmd run by the collector for (0 if not cached).
+// TTLs of commands take precedence over TTLs of collectors.
+func (c *CacheConfig) TTL(collector, cmd string) time.Duration {
+	if ttl, found := c.Commands[cmd]; found {
+		return ttl
+	}
+
+	return c.Collectors[collector]
 }
 
 func (c *Config) load(dynamicIfaceLabels bool) error {
+	err := c.applyGroups()
+	if err != nil {
+		return err
+	}
+
 	if c.IfDescReStr != "" && dynamicIfaceLabels {
 		re, err := regexp.Compile(c.IfDescReStr)
 		if err != nil {
@@ -31,20 +180,158 @@ func (c *Config) load(dynamicIfaceLabels bool) error {
 		c.IfDescReg = re
 	}
 
+	err = validateLabels(c.Labels)
+	if err != nil {
+		return err
+	}
+
+	err = c.Features.validate()
+	if err != nil {
+		return err
+	}
+
+	for name, g := range c.Groups {
+		if g.Features != nil {
+			err := g.Features.validate()
+			if err != nil {
+				return fmt.Errorf("group %s: %w", name, err)
+			}
+		}
+	}
+
+	if c.InterfaceFilter != nil {
+		err := c.InterfaceFilter.load()
+		if err != nil {
+			return fmt.Errorf("interface filter: %w", err)
+		}
+	}
+
+	if c.RoutingInstances != nil {
+		err := c.RoutingInstances.load()
+		if err != nil {
+			return fmt.Errorf("routing instances: %w", err)
+		}
+	}
+
+	err = c.loadCustomCollectors()
+	if err != nil {
+		return err
+	}
+
+	for name, m := range c.Modules {
+		if m == nil {
+			return fmt.Errorf("module %s has no definition", name)
+		}
+
+		err := m.Features.validate()
+		if err != nil {
+			return fmt.Errorf("module %s: %w", name, err)
+		}
+
+		for _, k := range m.CustomCollectors {
+			if _, found := c.CustomCollectors[k]; !found {
+				return fmt.Errorf("module %s references undefined custom collector %q", name, k)
+			}
+		}
+
+		if m.AlarmFilter != "" {
+			_, err := regexp.Compile(m.AlarmFilter)
+			if err != nil {
+				return fmt.Errorf("unable to compile alarm filter of module %s %q: %w", name, m.AlarmFilter, err)
+			}
+		}
+
+		if m.IfDescRegStr != "" && dynamicIfaceLabels {
+			re, err := regexp.Compile(m.IfDescRegStr)
+			if err != nil {
+				return fmt.Errorf("unable to compile interface description regex of module %s %q: %w", name, m.IfDescRegStr, err)
+			}
+
+			m.IfDescReg = re
+		}
+	}
+
 	for _, d := range c.Devices {
 		if d.IfDescRegStr != "" && dynamicIfaceLabels {
-			re, err := regexp.Compile(c.IfDescReStr)
+			re, err := regexp.Compile(d.IfDescRegStr)
 			if err != nil {
-				return fmt.Errorf("unable to compile interfce description regex %q: %w", c.IfDescReStr, err)
+				return fmt.Errorf("unable to compile interfce description regex of device %s %q: %w", d.Host, d.IfDescRegStr, err)
 			}
 
 			d.IfDescReg = re
 		}
+
+		if d.InterfaceFilter != nil {
+			err := d.InterfaceFilter.load()
+			if err != nil {
+				return fmt.Errorf("interface filter of device %s: %w", d.Host, err)
+			}
+		}
+
+		if d.RoutingInstances != nil {
+			err := d.RoutingInstances.load()
+			if err != nil {
+				return fmt.Errorf("routing instances of device %s: %w", d.Host, err)
+			}
+		}
+
+		err := validateLabels(d.Labels)
+		if err != nil {
+			return fmt.Errorf("device %s: %w", d.Host, err)
+		}
+
+		if d.Features != nil {
+			err := d.Features.validate()
+			if err != nil {
+				return fmt.Errorf("device %s: %w", d.Host, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) loadCustomCollectors() error {
+	metrics := make(map[string]string)
+	deviceLabels := c.deviceLabelNames()
+
+	for _, name := range slices.Sorted(maps.Keys(c.CustomCollectors)) {
+		def := c.CustomCollectors[name]
+		if !labelNameRegex.MatchString(name) || def == nil {
+			return fmt.Errorf("invalid custom collector %q", name)
+		}
+
+		err := def.Load(deviceLabels)
+		if err != nil {
+			return fmt.Errorf("custom collector %s: %w", name, err)
+		}
+
+		for _, m := range def.Metrics {
+			if other, found := metrics[m.Name]; found {
+				return fmt.Errorf("custom collector %s: metric %s is already defined by custom collector %s", name, m.Name, other)
+			}
+
+			metrics[m.Name] = name
+		}
 	}
 
 	return nil
 }
 
+// deviceLabelNames returns the names of the labels configured globally or for a device
+func (c *Config) deviceLabelNames() []string {
+	names := slices.Collect(maps.Keys(c.Labels))
+	for _, d := range c.Devices {
+		for name := range d.Labels {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names
+}
+
 // DeviceConfig is the config representation of 1 device
 type DeviceConfig struct {
 	Host          string         `yaml:"host"`
@@ -57,6 +344,120 @@ type DeviceConfig struct {
 	IfDescReg     *regexp.Regexp `yaml:"-"`
 	IsHostPattern bool           `yaml:"host_pattern,omitempty"`
 	HostPattern   *regexp.Regexp
+	Transport     string `yaml:"transport,omitempty"`
+
+	KnownHostsFile      string   `yaml:"known_hosts_file,omitempty"`
+	HostKeyFingerprints []string `yaml:"host_key_fingerprints,omitempty"`
+
+	CertFile    string   `yaml:"cert_file,omitempty"`
+	AuthMethods []string `yaml:"auth_methods,omitempty"`
+
+	// JumpHosts references the jump hosts (by name) to connect through, starting with the first one
+	JumpHosts []string `yaml:"jump_hosts,omitempty"`
+
+	// Proxy is the URL of the proxy to connect through, "direct" disables proxies for the device
+	Proxy string `yaml:"proxy,omitempty"`
+
+	// Concurrency is the number of collectors running concurrently on the device (each in its own SSH or NETCONF session)
+	Concurrency int `yaml:"concurrency,omitempty"`
+
+	// PollingInterval enables polling of the device in the background (metrics are served from the last poll)
+	PollingInterval time.Duration `yaml:"polling_interval,omitempty"`
+
+	// InterfaceFilter selects the interfaces metrics are exported for (replaces the global filter)
+	InterfaceFilter *InterfaceFilterConfig `yaml:"interface_filter,omitempty"`
+
+	// Labels are added to all metrics of the device. For host patterns values can reference capture groups of the pattern (e.g. $1).
+	Labels map[string]string `yaml:"labels,omitempty"`
+
+	// Port is the SSH port of the device (overrides a port in the host)
+	Port int `yaml:"port,omitempty"`
+
+	// ConnectTimeout, KeepAliveInterval, KeepAliveTimeout, ExpireTimeout and CommandTimeout override the corresponding flags
+	ConnectTimeout    time.Duration `yaml:"connect_timeout,omitempty"`
+	KeepAliveInterval time.Duration `yaml:"keep_alive_interval,omitempty"`
+	KeepAliveTimeout  time.Duration `yaml:"keep_alive_timeout,omitempty"`
+	ExpireTimeout     time.Duration `yaml:"expire_timeout,omitempty"`
+	CommandTimeout    time.Duration `yaml:"command_timeout,omitempty"`
+
+	// RoutingInstances configures the routing instance awareness of protocol collectors (replaces the global setting)
+	RoutingInstances *RoutingInstanceConfig `yaml:"routing_instances,omitempty"`
+
+	// LogicalSystems are scraped in addition to the device itself by all collectors supporting logical systems
+	LogicalSystems []string `yaml:"logical_systems,omitempty"`
+
+	// DiscoverLogicalSystems enables scraping all logical systems in the configuration of the device
+	DiscoverLogicalSystems *bool `yaml:"discover_logical_systems,omitempty"`
+
+	// Debug enables (or disables) verbose debug output for the device regardless of the debug flag
+	Debug *bool `yaml:"debug,omitempty"`
+
+	// Groups references the groups (by name) to inherit settings from. Settings of later groups take precedence over earlier ones.
+	Groups []string `yaml:"groups,omitempty"`
+
+	// featureValues are the features explicitly enabled or disabled (by YAML key), so unset features can be inherited
+	featureValues map[string]bool
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler interface
+func (d *DeviceConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain DeviceConfig
+	err := unmarshal((*plain)(d))
+	if err != nil {
+		return err
+	}
+
+	raw := struct {
+		Features map[string]bool `yaml:"features"`
+	}{}
+	err = unmarshal(&raw)
+	if err != nil {
+		return err
+	}
+
+	d.featureValues = raw.Features
+	return nil
+}
+
+// explicitFeatures returns the features explicitly set for the device (by YAML key)
+func (d *DeviceConfig) explicitFeatures() map[string]bool {
+	if d.featureValues != nil || d.Features == nil {
+		return d.featureValues
+	}
+
+	// features not read from YAML (e.g. created in code) are all considered set
+	return d.Features.values()
+}
+
+// JumpHostConfig is the config representation of a jump host (bastion) used to reach devices
+type JumpHostConfig struct {
+	Name                string   `yaml:"name"`
+	Host                string   `yaml:"host"`
+	Username            string   `yaml:"username,omitempty"`
+	Password            string   `yaml:"password,omitempty"`
+	KeyFile             string   `yaml:"key_file,omitempty"`
+	KeyPassphrase       string   `yaml:"key_passphrase,omitempty"`
+	CertFile            string   `yaml:"cert_file,omitempty"`
+	AuthMethods         []string `yaml:"auth_methods,omitempty"`
+	KnownHostsFile      string   `yaml:"known_hosts_file,omitempty"`
+	HostKeyFingerprints []string `yaml:"host_key_fingerprints,omitempty"`
+	Proxy               string   `yaml:"proxy,omitempty"`
+}
+
+// DeviceConfig returns the connection relevant settings of the jump host as device config
+func (j *JumpHostConfig) DeviceConfig() *DeviceConfig {
+	return &DeviceConfig{
+		Host:                j.Host,
+		Username:            j.Username,
+		Password:            j.Password,
+		KeyFile:             j.KeyFile,
+		KeyPassphrase:       j.KeyPassphrase,
+		CertFile:            j.CertFile,
+		AuthMethods:         j.AuthMethods,
+		KnownHostsFile:      j.KnownHostsFile,
+		HostKeyFingerprints: j.HostKeyFingerprints,
+		Proxy:               j.Proxy,
+	}
 }
 
 // FeatureConfig is the list of collectors enabled or disabled
@@ -108,6 +509,9 @@ type FeatureConfig struct {
 	KRT                 bool `yaml:"krt,omitempty"`
 	TWAMP               bool `yaml:"twamp,omitempty"`
 	SystemStatistics    bool `yaml:"system_statistics,omitempty"`
+
+	// Other are the features of registered collectors without a field (e.g. collectors compiled in from other packages)
+	Other map[string]bool `yaml:",inline"`
 }
 
 // New creates a new config
@@ -138,7 +542,63 @@ func Load(reader io.Reader, dynamicIfaceLabels bool) (*Config, error) {
 		return nil, err
 	}
 
+	err = ValidateAuthMethods(c.AuthMethods)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Concurrency < 0 {
+		return nil, fmt.Errorf("concurrency must not be negative")
+	}
+
+	if c.MetricsProfile != "" {
+		err = metricprofile.Validate(c.MetricsProfile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, j := range c.JumpHosts {
+		if j.Name == "" || j.Host == "" {
+			return nil, fmt.Errorf("jump hosts require a name and a host")
+		}
+
+		if c.FindJumpHost(j.Name) != j {
+			return nil, fmt.Errorf("jump host %s is defined more than once", j.Name)
+		}
+
+		err = ValidateAuthMethods(j.AuthMethods)
+		if err != nil {
+			return nil, fmt.Errorf("jump host %s: %w", j.Name, err)
+		}
+	}
+
 	for _, device := range c.Devices {
+		switch device.Transport {
+		case "", TransportCLI, TransportNetconf:
+		default:
+			return nil, fmt.Errorf("invalid transport %q for device %s (expected: %s or %s)", device.Transport, device.Host, TransportCLI, TransportNetconf)
+		}
+
+		err = ValidateAuthMethods(device.AuthMethods)
+		if err != nil {
+			return nil, fmt.Errorf("device %s: %w", device.Host, err)
+		}
+
+		if device.Concurrency < 0 {
+			return nil, fmt.Errorf("device %s: concurrency must not be negative", device.Host)
+		}
+
+		if device.Port < 0 || device.Port > 65535 {
+			return nil, fmt.Errorf("device %s: invalid port %d", device.Host, device.Port)
+		}
+
+		for _, name := range device.JumpHosts {
+			if c.FindJumpHost(name) == nil {
+				return nil, fmt.Errorf("device %s: jump host %s is not defined", device.Host, name)
+			}
+		}
+
 		if device.IsHostPattern {
 			hostPattern, err := regexp.Compile(device.Host)
 			if err != nil {
@@ -151,57 +611,156 @@ func Load(reader io.Reader, dynamicIfaceLabels bool) (*Config, error) {
 	return c, nil
 }
 
+// ValidateAuthMethods checks if all authentication methods are known
+func ValidateAuthMethods(methods []string) error {
+	for _, m := range methods {
+		switch m {
+		case AuthMethodKey, AuthMethodCertificate, AuthMethodAgent, AuthMethodPassword, AuthMethodKeyboardInteractive:
+		default:
+			return fmt.Errorf("invalid authentication method %q", m)
+		}
+	}
+
+	return nil
+}
+
 func setDefaultValues(c *Config) {
 	c.Password = ""
 	c.LSEnabled = false
-	f := &c.Features
-	f.AAA = false
-	f.Alarm = true
-	f.NTP = false
-	f.BGP = true
-	f.Environment = true
-	f.Interfaces = true
-	f.InterfaceDiagnostic = true
-	f.InterfaceQueue = true
-	f.IPSec = false
-	f.OSPF = true
-	f.ISIS = true
-	f.LDP = true
-	f.LLDP = false
-	f.Routes = true
-	f.Firewall = true
-	f.RoutingEngine = true
-	f.Security = false
-	f.SecurityPolicies = false
-	f.Storage = false
-	f.Accounting = false
-	f.FPC = false
-	f.L2Circuit = false
-	f.L2Vpn = false
-	f.RPKI = false
-	f.RPM = false
-	f.Satellite = false
-	f.Power = false
-	f.MAC = false
-	f.MPLSLSP = false
-	f.VPWS = false
-	f.VRRP = false
-	f.BFD = false
-	f.License = false
-	f.MACSec = true
-	f.Poe = false
-	f.SystemStatistics = true
-}
-
-// FeaturesForDevice gets the feature set configured for a device
+
+	for _, r := range collector.Registrations() {
+		c.Features.Set(r.Feature, r.Default&collector.EnabledInConfig != 0)
+	}
+}
+
+// FeaturesForDevice gets the feature set configured for a device.
+// Features set for the device take precedence over features set in its groups, which take precedence over global features.
 func (c *Config) FeaturesForDevice(host string) *FeatureConfig {
 	d := c.FindDeviceConfig(host)
+	if d == nil {
+		return &c.Features
+	}
+
+	f := c.Features
+	f.set(d.explicitFeatures())
+
+	return &f
+}
+
+// applyGroups merges the settings of the referenced groups into the devices (settings of the device take precedence)
+func (c *Config) applyGroups() error {
+	for name, g := range c.Groups {
+		if g == nil {
+			return fmt.Errorf("group %s has no definition", name)
+		}
+
+		if g.Host != "" || g.IsHostPattern || len(g.Groups) > 0 {
+			return fmt.Errorf("group %s: host, host_pattern and groups can not be set in groups", name)
+		}
+	}
+
+	for _, d := range c.Devices {
+		if len(d.Groups) == 0 {
+			continue
+		}
+
+		for _, name := range d.Groups {
+			if c.Groups[name] == nil {
+				return fmt.Errorf("device %s: group %s is not defined", d.Host, name)
+			}
+		}
+
+		// settings already set are kept, so the last group is applied first
+		for i := len(d.Groups) - 1; i >= 0; i-- {
+			d.inherit(c.Groups[d.Groups[i]])
+		}
+
+		features := make(map[string]bool)
+		for _, name := range d.Groups {
+			for k, v := range c.Groups[name].explicitFeatures() {
+				features[k] = v
+			}
+		}
+
+		for k, v := range d.explicitFeatures() {
+			features[k] = v
+		}
+
+		d.featureValues = features
+	}
+
+	return nil
+}
+
+// inherit sets all settings not set for the device to the value of the group. Labels are merged.
+func (d *DeviceConfig) inherit(g *DeviceConfig) {
+	v := reflect.ValueOf(d).Elem()
+	o := reflect.ValueOf(g).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		switch t.Field(i).Name {
+		case "Host", "IsHostPattern", "HostPattern", "Groups", "Features", "IfDescReg", "Labels":
+			continue
+		}
+
+		if !t.Field(i).IsExported() {
+			continue
+		}
+
+		if v.Field(i).IsZero() {
+			v.Field(i).Set(o.Field(i))
+		}
+	}
 
-	if d != nil && d.Features != nil {
-		return d.Features
+	if len(g.Labels) > 0 && d.Labels == nil {
+		d.Labels = make(map[string]string)
 	}
 
-	return &c.Features
+	for k, val := range g.Labels {
+		if _, found := d.Labels[k]; !found {
+			d.Labels[k] = val
+		}
+	}
+}
+
+// LabelsForDevice returns the labels to add to all metrics of the device (device labels take precedence over global labels).
+// References to capture groups in labels of host patterns are replaced by the matching part of the host.
+func (c *Config) LabelsForDevice(host string) map[string]string {
+	labels := make(map[string]string)
+	for k, v := range c.Labels {
+		labels[k] = v
+	}
+
+	d := c.FindDeviceConfig(host)
+	if d == nil {
+		return labels
+	}
+
+	for k, v := range d.Labels {
+		if d.HostPattern != nil {
+			m := d.HostPattern.FindStringSubmatchIndex(host)
+			v = string(d.HostPattern.ExpandString(nil, v, host, m))
+		}
+
+		labels[k] = v
+	}
+
+	return labels
+}
+
+func validateLabels(labels map[string]string) error {
+	for name := range labels {
+		if !labelNameRegex.MatchString(name) || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("invalid label name %q", name)
+		}
+
+		if name == "target" || name == "logical_system" || name == "routing_instance" {
+			return fmt.Errorf("label name %q is reserved", name)
+		}
+	}
+
+	return nil
 }
 
 func (c *Config) FindDeviceConfig(host string) *DeviceConfig {
@@ -219,3 +778,145 @@ func (c *Config) FindDeviceConfig(host string) *DeviceConfig {
 
 	return nil
 }
+
+// ModuleForNames combines the modules with the given names into one module.
+// The features of the modules are merged, options are taken from the first module setting them.
+func (c *Config) ModuleForNames(names []string) (*ModuleConfig, error) {
+	merged := &ModuleConfig{}
+
+	for _, name := range names {
+		m, found := c.Modules[name]
+		if !found {
+			return nil, fmt.Errorf("module %q is not defined in the configuration file", name)
+		}
+
+		merged.Features.merge(&m.Features)
+
+		if merged.AlarmFilter == "" {
+			merged.AlarmFilter = m.AlarmFilter
+		}
+
+		if merged.IfDescReg == nil {
+			merged.IfDescRegStr = m.IfDescRegStr
+			merged.IfDescReg = m.IfDescReg
+		}
+
+		for _, k := range m.CustomCollectors {
+			if !slices.Contains(merged.CustomCollectors, k) {
+				merged.CustomCollectors = append(merged.CustomCollectors, k)
+			}
+		}
+	}
+
+	return merged, nil
+}
+
+// Enabled returns if the feature with the given YAML key is enabled
+func (f *FeatureConfig) Enabled(feature string) bool {
+	if v, found := f.field(feature); found {
+		return v.Bool()
+	}
+
+	return f.Other[feature]
+}
+
+// Set enables or disables the feature with the given YAML key
+func (f *FeatureConfig) Set(feature string, enabled bool) {
+	if v, found := f.field(feature); found {
+		v.SetBool(enabled)
+		return
+	}
+
+	// copied on write, so copies of the config do not share the map
+	other := maps.Clone(f.Other)
+	if other == nil {
+		other = make(map[string]bool)
+	}
+
+	other[feature] = enabled
+	f.Other = other
+}
+
+// featureFields maps the YAML keys of the feature fields to their index in FeatureConfig
+var featureFields = featureFieldIndex()
+
+func featureFieldIndex() map[string]int {
+	t := reflect.TypeOf(FeatureConfig{})
+
+	fields := make(map[string]int)
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Type.Kind() == reflect.Bool {
+			fields[featureKey(t.Field(i))] = i
+		}
+	}
+
+	return fields
+}
+
+// field returns the field of the feature with the given YAML key
+func (f *FeatureConfig) field(feature string) (reflect.Value, bool) {
+	i, found := featureFields[feature]
+	if !found {
+		return reflect.Value{}, false
+	}
+
+	return reflect.ValueOf(f).Elem().Field(i), true
+}
+
+// values returns the values of all features by YAML key
+func (f *FeatureConfig) values() map[string]bool {
+	v := reflect.ValueOf(f).Elem()
+
+	values := make(map[string]bool, len(featureFields)+len(f.Other))
+	for k, i := range featureFields {
+		values[k] = v.Field(i).Bool()
+	}
+
+	for k, enabled := range f.Other {
+		values[k] = enabled
+	}
+
+	return values
+}
+
+// set sets the features to the values given by YAML key
+func (f *FeatureConfig) set(values map[string]bool) {
+	for k, enabled := range values {
+		f.Set(k, enabled)
+	}
+}
+
+func featureKey(f reflect.StructField) string {
+	return strings.Split(f.Tag.Get("yaml"), ",")[0]
+}
+
+// merge enables all features enabled in other
+func (f *FeatureConfig) merge(other *FeatureConfig) {
+	for k, enabled := range other.values() {
+		if enabled {
+			f.Set(k, true)
+		}
+	}
+}
+
+// validate returns an error if a feature is neither a field nor the feature of a registered collector
+func (f *FeatureConfig) validate() error {
+	for _, k := range slices.Sorted(maps.Keys(f.Other)) {
+		if collector.ForFeature(k) == nil {
+			return fmt.Errorf("unknown feature %q", k)
+		}
+	}
+
+	return nil
+}
+
+// FindJumpHost gets the jump host with the given name
+func (c *Config) FindJumpHost(name string) *JumpHostConfig {
+	for _, j := range c.JumpHosts {
+		if j.Name == name {
+			return j
+		}
+	}
+
+	return nil
+}
@@ -7,8 +7,13 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/czerwonk/junos_exporter/pkg/collector"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/all"
+	"github.com/czerwonk/junos_exporter/pkg/features/custom"
 )
 
 func TestShouldParse(t *testing.T) {
@@ -213,3 +218,303 @@ func TestFindDeviceConfig(t *testing.T) {
 		t.Fatal("Unexpected device for switch-oob")
 	}
 }
+
+func TestShouldRejectInvalidTransport(t *testing.T) {
+	b, err := os.ReadFile("tests/config7.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Load(bytes.NewReader(b), true)
+	assert.EqualError(t, err, `invalid transport "telnet" for device router2 (expected: cli or netconf)`)
+}
+
+func TestShouldParseJumpHosts(t *testing.T) {
+	b, err := os.ReadFile("tests/config8.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(bytes.NewReader(b), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, len(c.JumpHosts), "jump hosts")
+	assert.Equal(t, []string{"bastion1", "bastion2"}, c.Devices[0].JumpHosts, "Device 1: Jump hosts")
+	assert.Empty(t, c.Devices[1].JumpHosts, "Device 2: Jump hosts")
+
+	j := c.FindJumpHost("bastion2")
+	assert.Equal(t, "bastion2.example.com:2222", j.Host, "Jump host 2: Host")
+	assert.Equal(t, "secret", j.DeviceConfig().Password, "Jump host 2: Password")
+}
+
+func TestShouldRejectUndefinedJumpHost(t *testing.T) {
+	_, err := Load(bytes.NewReader([]byte("devices:\n  - host: router1\n    jump_hosts: [bastion]\n")), true)
+	assert.EqualError(t, err, "device router1: jump host bastion is not defined")
+}
+
+func TestShouldParseCacheTTLs(t *testing.T) {
+	b, err := os.ReadFile("tests/config9.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(bytes.NewReader(b), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, c.Cache.Enabled())
+	assert.Equal(t, 6*time.Hour, c.Cache.TTL("ifacediag", "show chassis hardware"), "command TTL should take precedence")
+	assert.Equal(t, time.Hour, c.Cache.TTL("ifacediag", "show interfaces diagnostics optics"))
+	assert.Equal(t, 30*time.Minute, c.Cache.TTL("system", "show system information"))
+	assert.Equal(t, time.Duration(0), c.Cache.TTL("bgp", "show bgp neighbor"))
+}
+
+func TestShouldMergeModules(t *testing.T) {
+	b, err := os.ReadFile("tests/config10.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(bytes.NewReader(b), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, 2, len(c.Modules), "modules")
+
+	m, err := c.ModuleForNames([]string{"fast", "routing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, m.Features.Interfaces, "Interfaces")
+	assert.True(t, m.Features.Alarm, "Alarm")
+	assert.True(t, m.Features.BGP, "BGP")
+	assert.True(t, m.Features.OSPF, "OSPF")
+	assert.False(t, m.Features.ISIS, "ISIS")
+	assert.Equal(t, "Minor", m.AlarmFilter, "AlarmFilter")
+	assert.NotNil(t, m.IfDescReg, "IfDescReg")
+
+	_, err = c.ModuleForNames([]string{"fast", "slow"})
+	assert.EqualError(t, err, `module "slow" is not defined in the configuration file`)
+}
+
+func TestShouldParseInterfaceFilters(t *testing.T) {
+	b, err := os.ReadFile("tests/config11.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(bytes.NewReader(b), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := c.InterfaceFilter.Filter
+	assert.True(t, f.Matches("xe-0/0/0.0"))
+	assert.False(t, f.Matches("xe-0/0/0.32767"))
+	assert.False(t, f.Matches("pfh-0/0/0"))
+
+	f = c.FindDeviceConfig("router2").InterfaceFilter.Filter
+	assert.True(t, f.Matches("xe-0/0/0"))
+	assert.False(t, f.Matches("xe-0/0/0.0"))
+	assert.False(t, f.Matches("et-0/0/0"))
+}
+
+func TestShouldRejectInvalidInterfaceFilter(t *testing.T) {
+	_, err := Load(bytes.NewReader([]byte("interface_filter:\n  include: ['(']\n")), true)
+	assert.ErrorContains(t, err, `interface filter: invalid include pattern "("`)
+}
+
+func TestShouldResolveLabelsForDevice(t *testing.T) {
+	b, err := os.ReadFile("tests/config12.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(bytes.NewReader(b), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, map[string]string{"env": "prod", "site": "unknown", "role": "core"}, c.LabelsForDevice("router1"))
+	assert.Equal(t, map[string]string{"env": "prod", "site": "fra", "role": "edge-router"}, c.LabelsForDevice("fra1-edge2"))
+	assert.Equal(t, map[string]string{"env": "prod", "site": "unknown"}, c.LabelsForDevice("router2"))
+}
+
+func TestShouldRejectInvalidLabels(t *testing.T) {
+	_, err := Load(bytes.NewReader([]byte("labels:\n  target: foo\n")), true)
+	assert.ErrorContains(t, err, `label name "target" is reserved`)
+
+	_, err = Load(bytes.NewReader([]byte("devices:\n  - host: router1\n    labels:\n      site-name: foo\n")), true)
+	assert.ErrorContains(t, err, `device router1: invalid label name "site-name"`)
+}
+
+func TestShouldMergeGroupsIntoDevices(t *testing.T) {
+	b, err := os.ReadFile("tests/config13.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(bytes.NewReader(b), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := c.FindDeviceConfig("router1")
+	assert.Equal(t, "core", d.Username, "Username")
+	assert.Equal(t, "other", d.Password, "Password")
+	assert.Equal(t, 30*time.Second, d.PollingInterval, "PollingInterval")
+	assert.Equal(t, map[string]string{"env": "prod", "role": "border", "site": "ber"}, c.LabelsForDevice("router1"))
+
+	f := c.FeaturesForDevice("router1")
+	assertFeature("BGP", f.BGP, true, t)
+	assertFeature("OSPF", f.OSPF, true, t)
+	assertFeature("ISIS", f.ISIS, true, t)
+	assertFeature("LLDP", f.LLDP, true, t)
+	assertFeature("Alarm", f.Alarm, true, t)
+
+	f = c.FeaturesForDevice("router2")
+	assertFeature("BGP", f.BGP, false, t)
+	assertFeature("OSPF", f.OSPF, true, t)
+	assertFeature("Alarm", f.Alarm, true, t)
+}
+
+func TestShouldRejectUndefinedGroup(t *testing.T) {
+	_, err := Load(bytes.NewReader([]byte("devices:\n  - host: router1\n    groups: [core]\n")), true)
+	assert.ErrorContains(t, err, "device router1: group core is not defined")
+
+	_, err = Load(bytes.NewReader([]byte("groups:\n  core:\n    host: router1\n")), true)
+	assert.ErrorContains(t, err, "group core: host, host_pattern and groups can not be set in groups")
+}
+
+func TestShouldParseConnectionSettingsOfDevice(t *testing.T) {
+	b, err := os.ReadFile("tests/config14.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(bytes.NewReader(b), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := c.FindDeviceConfig("router1")
+	assert.Equal(t, 2222, d.Port, "Port")
+	assert.Equal(t, 20*time.Second, d.ConnectTimeout, "ConnectTimeout")
+	assert.Equal(t, 30*time.Second, d.KeepAliveInterval, "KeepAliveInterval")
+	assert.Equal(t, 45*time.Second, d.KeepAliveTimeout, "KeepAliveTimeout")
+	assert.Equal(t, time.Hour, d.ExpireTimeout, "ExpireTimeout")
+	assert.Equal(t, time.Minute, d.CommandTimeout, "CommandTimeout")
+	if assert.NotNil(t, d.Debug, "Debug") {
+		assert.False(t, *d.Debug, "Debug")
+	}
+
+	f := c.FeaturesForDevice("router1")
+	assertFeature("Satellite", f.Satellite, true, t)
+	assertFeature("License", f.License, true, t)
+}
+
+func TestShouldParseRoutingInstances(t *testing.T) {
+	b, err := os.ReadFile("tests/config15.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(bytes.NewReader(b), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.False(t, c.RoutingInstances.FanOut, "FanOut")
+	assert.True(t, c.RoutingInstances.Filter.Matches("CUST-A"))
+	assert.False(t, c.RoutingInstances.Filter.Matches("INTERNET"))
+
+	r := c.FindDeviceConfig("router2").RoutingInstances
+	assert.True(t, r.FanOut, "FanOut")
+	assert.Equal(t, []string{"CUST-A", "INTERNET"}, r.Instances)
+	assert.True(t, r.Filter.Matches("INTERNET"))
+}
+
+func TestShouldParseCustomCollectors(t *testing.T) {
+	b, err := os.ReadFile("tests/config16.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(bytes.NewReader(b), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, c.CustomCollectors, 2)
+
+	def := c.CustomCollectors["krt_queue"]
+	assert.Equal(t, "show krt queue", def.Command)
+	assert.Equal(t, map[string]string{"type": "krtq-type"}, def.Labels)
+	if assert.Len(t, def.Metrics, 1) {
+		assert.Equal(t, custom.TypeGauge, def.Metrics[0].Type, "default type")
+		assert.Equal(t, 1.0, def.Metrics[0].Scale, "default scale")
+	}
+
+	m, err := c.ModuleForNames([]string{"routing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"krt_queue"}, m.CustomCollectors)
+}
+
+func TestShouldRejectInvalidCustomCollectors(t *testing.T) {
+	_, err := Load(bytes.NewReader([]byte("custom_collectors:\n  krt:\n    command: show krt queue\n")), true)
+	assert.ErrorContains(t, err, "custom collector krt: at least one metric is required")
+
+	_, err = Load(bytes.NewReader([]byte("custom_collectors:\n  a:\n    command: show a\n    metrics: [{name: x, path: x}]\n  b:\n    command: show b\n    metrics: [{name: x, path: x}]\n")), true)
+	assert.ErrorContains(t, err, "custom collector b: metric x is already defined by custom collector a")
+
+	_, err = Load(bytes.NewReader([]byte("modules:\n  m:\n    custom_collectors: [foo]\n")), true)
+	assert.ErrorContains(t, err, `module m references undefined custom collector "foo"`)
+
+	_, err = Load(bytes.NewReader([]byte("custom_collectors:\n  a:\n    command: show a\n    labels: {role: x}\n    metrics: [{name: x, path: x}]\ndevices:\n  - host: router1\n    labels: {role: edge}\n")), true)
+	assert.ErrorContains(t, err, `custom collector a: label name "role" is already used by the labels configured for devices`)
+}
+
+func init() {
+	// collector compiled in from another package (without field in FeatureConfig)
+	collector.Register(&collector.Registration{
+		Key: "example",
+		New: func(collector.Options) collector.RPCCollector {
+			return nil
+		},
+	})
+}
+
+func TestShouldParseFeaturesOfRegisteredCollectors(t *testing.T) {
+	b, err := os.ReadFile("tests/config17.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(bytes.NewReader(b), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.False(t, c.Features.Enabled("bgp"), "bgp")
+	assert.True(t, c.Features.Enabled("example"), "example")
+	assert.True(t, c.Features.Enabled("interfaces"), "default of interfaces")
+
+	assert.False(t, c.FeaturesForDevice("router1").Enabled("example"), "router1")
+	assert.True(t, c.FeaturesForDevice("router2").Enabled("example"), "router2")
+	assert.True(t, c.Features.Enabled("example"), "global features are not changed by device features")
+}
+
+func TestShouldRejectUnknownFeatures(t *testing.T) {
+	_, err := Load(bytes.NewReader([]byte("features:\n  foo: true\n")), true)
+	assert.ErrorContains(t, err, `unknown feature "foo"`)
+
+	_, err = Load(bytes.NewReader([]byte("devices:\n  - host: router1\n    features:\n      foo: true\n")), true)
+	assert.ErrorContains(t, err, `device router1: unknown feature "foo"`)
+}
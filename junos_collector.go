@@ -4,13 +4,20 @@ package main
 
 import (
 	"context"
+	"errors"
 	"regexp"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/czerwonk/junos_exporter/internal/config"
+	"github.com/czerwonk/junos_exporter/pkg/cache"
+	"github.com/czerwonk/junos_exporter/pkg/collector"
 	"github.com/czerwonk/junos_exporter/pkg/connector"
 	"github.com/czerwonk/junos_exporter/pkg/dynamiclabels"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
+	"github.com/czerwonk/junos_exporter/pkg/metricprofile"
+	"github.com/czerwonk/junos_exporter/pkg/routinginstance"
 	"github.com/czerwonk/junos_exporter/pkg/rpc"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel/attribute"
@@ -24,14 +31,22 @@ const prefix = "junos_"
 
 var (
 	scrapeCollectorDurationDesc *prometheus.Desc
+	scrapeCollectorTimeoutDesc  *prometheus.Desc
 	scrapeDurationDesc          *prometheus.Desc
 	upDesc                      *prometheus.Desc
+	hostKeyMismatchesDesc       *prometheus.Desc
+	breakerStateDesc            *prometheus.Desc
+	nextRetryDesc               *prometheus.Desc
 )
 
 func init() {
 	upDesc = prometheus.NewDesc(prefix+"up", "Scrape of target was successful", []string{"target"}, nil)
 	scrapeDurationDesc = prometheus.NewDesc(prefix+"collector_duration_seconds", "Duration of a collector scrape for one target", []string{"target"}, nil)
 	scrapeCollectorDurationDesc = prometheus.NewDesc(prefix+"collect_duration_seconds", "Duration of a scrape by collector and target", []string{"target", "collector"}, nil)
+	scrapeCollectorTimeoutDesc = prometheus.NewDesc(prefix+"collect_timed_out", "Collector did not finish before the scrape deadline", []string{"target", "collector"}, nil)
+	hostKeyMismatchesDesc = prometheus.NewDesc(prefix+"ssh_host_key_mismatches_total", "Number of connections blocked because of a SSH host key mismatch", []string{"target"}, nil)
+	breakerStateDesc = prometheus.NewDesc(prefix+"connection_breaker_state", "State of the circuit breaker of the connection to the target (0 = closed, 1 = half-open, 2 = open/marked down)", []string{"target"}, nil)
+	nextRetryDesc = prometheus.NewDesc(prefix+"connection_next_retry_timestamp_seconds", "Time of the next connection attempt to a target marked down (0 if the target is not marked down)", []string{"target"}, nil)
 }
 
 type junosCollector struct {
@@ -41,7 +56,7 @@ type junosCollector struct {
 	ctx        context.Context
 }
 
-func newJunosCollector(ctx context.Context, devices []*connector.Device, logicalSystem string) *junosCollector {
+func newJunosCollector(ctx context.Context, devices []*connector.Device, logicalSystem string, module *config.ModuleConfig, only []string) *junosCollector {
 	clients := make(map[*connector.Device]*rpc.Client)
 
 	for _, d := range devices {
@@ -56,7 +71,7 @@ func newJunosCollector(ctx context.Context, devices []*connector.Device, logical
 
 	return &junosCollector{
 		devices:    devices,
-		collectors: collectorsForDevices(devices, cfg, logicalSystem),
+		collectors: collectorsForDevices(devices, cfg, logicalSystem, module, only),
 		clients:    clients,
 		ctx:        ctx,
 	}
@@ -76,25 +91,114 @@ func deviceInterfaceRegex(cfg *config.Config, host string) *regexp.Regexp {
 	return dynamiclabels.DefaultInterfaceDescRegex()
 }
 
+// deviceInterfaceFilter returns the interface filter of the device (nil if all interfaces are selected)
+func deviceInterfaceFilter(cfg *config.Config, host string) *interfacefilter.Filter {
+	dc := cfg.FindDeviceConfig(host)
+	if dc != nil && dc.InterfaceFilter != nil {
+		return dc.InterfaceFilter.Filter
+	}
+
+	if cfg.InterfaceFilter != nil {
+		return cfg.InterfaceFilter.Filter
+	}
+
+	return nil
+}
+
+// deviceRoutingInstances returns the routing instance settings of the device (nil if routing instance awareness is disabled)
+func deviceRoutingInstances(cfg *config.Config, host string) *config.RoutingInstanceConfig {
+	dc := cfg.FindDeviceConfig(host)
+	if dc != nil && dc.RoutingInstances != nil {
+		return dc.RoutingInstances
+	}
+
+	return cfg.RoutingInstances
+}
+
+// deviceRoutingInstanceFilter returns the allow-list of routing instances of the device (nil if routing instance awareness is disabled)
+func deviceRoutingInstanceFilter(cfg *config.Config, host string) *routinginstance.Filter {
+	r := deviceRoutingInstances(cfg, host)
+	if r == nil {
+		return nil
+	}
+
+	return r.Filter
+}
+
+// activeMetricsProfile returns the metric profile set in the config file or by flag
+func activeMetricsProfile(cfg *config.Config) string {
+	if cfg.MetricsProfile != "" {
+		return cfg.MetricsProfile
+	}
+
+	return *metricsProfile
+}
+
+// deviceConcurrency returns the number of collectors allowed to run concurrently on the device
+func deviceConcurrency(cfg *config.Config, host string) int {
+	dc := cfg.FindDeviceConfig(host)
+	if dc != nil && dc.Concurrency > 0 {
+		return dc.Concurrency
+	}
+
+	if cfg.Concurrency > 0 {
+		return cfg.Concurrency
+	}
+
+	return *collectorConcurrency
+}
+
+// deviceDebug returns if verbose debug output is enabled for the device
+func deviceDebug(cfg *config.Config, host string) bool {
+	dc := cfg.FindDeviceConfig(host)
+	if dc != nil && dc.Debug != nil {
+		return *dc.Debug
+	}
+
+	return *debug
+}
+
+// deviceCommandTimeout returns the duration to wait for the output of a command on the device (0 = no timeout)
+func deviceCommandTimeout(cfg *config.Config, host string) time.Duration {
+	dc := cfg.FindDeviceConfig(host)
+	if dc != nil && dc.CommandTimeout > 0 {
+		return dc.CommandTimeout
+	}
+
+	return *sshCommandTimeout
+}
+
 func clientForDevice(device *connector.Device, connManager *connector.SSHConnectionManager) (*rpc.Client, error) {
 	conn, err := connManager.GetSSHConnection(device)
 	if err != nil {
 		return nil, err
 	}
 
-	opts := []rpc.ClientOption{}
-	if *debug {
+	opts := []rpc.ClientOption{
+		rpc.WithUnsupportedCommands(unsupportedCommands),
+	}
+
+	if deviceDebug(cfg, device.Host) {
 		opts = append(opts, rpc.WithDebug())
 	}
 
-	if cfg.Features.Satellite {
+	f := cfg.FeaturesForDevice(device.Host)
+	if f.Satellite {
 		opts = append(opts, rpc.WithSatellite())
 	}
 
-	if cfg.Features.License {
+	if f.License {
 		opts = append(opts, rpc.WithLicenseInformation())
 	}
 
+	if dc := cfg.FindDeviceConfig(device.Host); dc != nil && dc.Transport == config.TransportNetconf {
+		opts = append(opts, rpc.WithNetconf())
+	}
+
+	if timeout := deviceCommandTimeout(cfg, device.Host); timeout > 0 {
+		opts = append(opts, rpc.WithCommandTimeout(timeout))
+	}
+
 	c := rpc.NewClient(conn, opts...)
 	return c, nil
 }
@@ -104,6 +208,12 @@ func (c *junosCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- upDesc
 	ch <- scrapeDurationDesc
 	ch <- scrapeCollectorDurationDesc
+	ch <- scrapeCollectorTimeoutDesc
+	ch <- hostKeyMismatchesDesc
+	ch <- breakerStateDesc
+	ch <- nextRetryDesc
+	ch <- collectorSuccessDesc
+	ch <- collectorErrorsDesc
 
 	for _, col := range c.collectors.allEnabledCollectors() {
 		col.Describe(ch)
@@ -115,19 +225,60 @@ func (c *junosCollector) Collect(ch chan<- prometheus.Metric) {
 	ctx, span := tracer.Start(c.ctx, "Collect")
 	defer span.End()
 
+	if activeMetricsProfile(cfg) == metricprofile.V2 {
+		converted := make(chan prometheus.Metric)
+		done := make(chan struct{})
+		go convertMetrics(converted, ch, done)
+		defer func() {
+			close(converted)
+			<-done
+		}()
+
+		ch = converted
+	}
+
 	wg := &sync.WaitGroup{}
 
 	wg.Add(len(c.devices))
 	for _, d := range c.devices {
-		go c.collectForHost(ctx, d, ch, wg)
+		go func(d *connector.Device) {
+			defer wg.Done()
+			c.collectForHost(ctx, d, ch)
+		}(d)
 	}
 
 	wg.Wait()
 }
 
-func (c *junosCollector) collectForHost(ctx context.Context, device *connector.Device, ch chan<- prometheus.Metric, wg *sync.WaitGroup) {
-	defer wg.Done()
+// convertMetrics converts the metrics received from in to the v2 profile and sends them to out
+func convertMetrics(in <-chan prometheus.Metric, out chan<- prometheus.Metric, done chan<- struct{}) {
+	defer close(done)
+
+	for m := range in {
+		converted, err := metricConverter.Convert(m)
+		if err != nil {
+			log.Errorf("Could not convert metric %s: %v", m.Desc(), err)
+			continue
+		}
 
+		out <- converted
+	}
+}
+
+// collectForHost collects all metrics of the device and adds the labels configured for the device to them
+func (c *junosCollector) collectForHost(ctx context.Context, device *connector.Device, ch chan<- prometheus.Metric) {
+	labels := cfg.LabelsForDevice(device.Host)
+	if len(labels) == 0 {
+		c.collectDevice(ctx, device, ch)
+		return
+	}
+
+	collectWithDeviceLabels(device.Host, labels, ch, func(ch chan<- prometheus.Metric) {
+		c.collectDevice(ctx, device, ch)
+	})
+}
+
+func (c *junosCollector) collectDevice(ctx context.Context, device *connector.Device, ch chan<- prometheus.Metric) {
 	ctx, span := tracer.Start(ctx, "CollectForHost", trace.WithAttributes(
 		attribute.String("host", device.Host),
 	))
@@ -140,6 +291,16 @@ func (c *junosCollector) collectForHost(ctx context.Context, device *connector.D
 		ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, time.Since(t).Seconds(), l...)
 	}()
 
+	ch <- prometheus.MustNewConstMetric(hostKeyMismatchesDesc, prometheus.CounterValue, float64(connManager.HostKeyMismatches(device)), l...)
+
+	state, nextRetry := connManager.BreakerStatus(device)
+	ch <- prometheus.MustNewConstMetric(breakerStateDesc, prometheus.GaugeValue, float64(state), l...)
+	if nextRetry.IsZero() {
+		ch <- prometheus.MustNewConstMetric(nextRetryDesc, prometheus.GaugeValue, 0, l...)
+	} else {
+		ch <- prometheus.MustNewConstMetric(nextRetryDesc, prometheus.GaugeValue, float64(nextRetry.Unix()), l...)
+	}
+
 	cl, found := c.clients[device]
 	if !found {
 		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 0, l...)
@@ -148,26 +309,147 @@ func (c *junosCollector) collectForHost(ctx context.Context, device *connector.D
 
 	ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 1, l...)
 
-	for _, col := range c.collectors.collectorsForDevice(device) {
-		ctx, sp := tracer.Start(ctx, "CollectForHostWithCollector", trace.WithAttributes(
-			attribute.String("collector", col.Name()),
-		))
+	// collectors run concurrently (each in its own SSH session) limited by the concurrency setting of the device and the global limit
+	concurrency := deviceConcurrency(cfg, device.Host)
+	colWg := &sync.WaitGroup{}
+	c.runCollectors(ctx, device, cl, c.collectors, concurrency, ch, l, colWg)
+
+	// when no logical system is requested, the collectors supporting logical systems also run in all logical systems of the device
+	if c.collectors.logicalSystem == "" {
+		for _, ls := range c.logicalSystemsForDevice(ctx, device, cl) {
+			cols := c.collectors.forLogicalSystem(device, ls)
+			if cols == nil {
+				break
+			}
+
+			colWg.Add(1)
+			go func() {
+				defer colWg.Done()
+				collectWithLabels(map[string]string{"logical_system": ls}, ch, func(ch chan<- prometheus.Metric) {
+					lsWg := &sync.WaitGroup{}
+					c.runCollectors(ctx, device, cl, cols, concurrency, ch, l, lsWg)
+					lsWg.Wait()
+				})
+			}()
+		}
+	}
 
-		cta := &clientTracingAdapter{
-			cl:  cl,
-			ctx: ctx,
+	// the collectors supporting routing instances also run in the routing instances of the device (if enabled)
+	for _, instance := range c.routingInstancesForDevice(ctx, device, cl) {
+		cols := c.collectors.forRoutingInstance(device, instance)
+		if cols == nil {
+			break
 		}
 
-		ct := time.Now()
-		err := col.Collect(cta, ch, l)
+		c.runCollectors(ctx, device, cl, cols, concurrency, ch, l, colWg)
+	}
+
+	colWg.Wait()
+}
+
+// runCollectors starts all collectors of the device in cols (wg is done when all of them finished)
+func (c *junosCollector) runCollectors(ctx context.Context, device *connector.Device, cl *rpc.Client, cols *collectors, concurrency int, ch chan<- prometheus.Metric, l []string, wg *sync.WaitGroup) {
+	for _, col := range cols.collectorsForDevice(device) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			instance := instanceLabel(device, cols, col)
+			if instance == "" {
+				c.runCollector(ctx, device, cl, cols, col, concurrency, ch, l)
+				return
+			}
+
+			collectWithLabels(map[string]string{"routing_instance": instance}, ch, func(ch chan<- prometheus.Metric) {
+				c.runCollector(ctx, device, cl, cols, col, concurrency, ch, l)
+			})
+		}()
+	}
+}
+
+// instanceLabel returns the value of the routing_instance label to add to the metrics of the collector (empty if no label is added)
+func instanceLabel(device *connector.Device, cols *collectors, col collector.RPCCollector) string {
+	if cols.instance != "" {
+		return cols.instance
+	}
+
+	if deviceRoutingInstances(cfg, device.Host) != nil && slices.Contains(routingInstanceKeys, cols.keyOf(col)) {
+		return routinginstance.Master
+	}
+
+	return ""
+}
 
-		if err != nil && err.Error() != "EOF" {
-			sp.RecordError(err)
-			sp.SetStatus(codes.Error, err.Error())
-			log.Errorln(col.Name() + ": " + err.Error())
+func (c *junosCollector) runCollector(ctx context.Context, device *connector.Device, cl *rpc.Client, cols *collectors, col collector.RPCCollector, concurrency int, ch chan<- prometheus.Metric, l []string) {
+	release, err := collectorLimits.acquire(ctx, device.Host, concurrency)
+	if err != nil {
+		// the scrape deadline was hit before the collector was started
+		c.reportTimeout(device, cols, col.Name(), err, ch, l)
+		return
+	}
+	defer release()
+
+	ctx, sp := tracer.Start(ctx, "CollectForHostWithCollector", trace.WithAttributes(
+		attribute.String("collector", col.Name()),
+	))
+	defer sp.End()
+
+	var client collector.Client = &clientTracingAdapter{
+		cl:  cl,
+		ctx: rpc.ContextWithCollector(ctx, cols.keyOf(col)),
+	}
+
+	if cfg.Cache.Enabled() {
+		key := cols.keyOf(col)
+		client = cache.NewClient(client, responseCache, func(cmd string) time.Duration {
+			return cfg.Cache.TTL(key, cmd)
+		})
+	}
+
+	ct := time.Now()
+	err = col.Collect(client, ch, l)
+	ch <- prometheus.MustNewConstMetric(scrapeCollectorDurationDesc, prometheus.GaugeValue, time.Since(ct).Seconds(), append(l, col.Name())...)
+
+	if ctx.Err() != nil {
+		c.reportTimeout(device, cols, col.Name(), ctx.Err(), ch, l)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(scrapeCollectorTimeoutDesc, prometheus.GaugeValue, 0, append(l, col.Name())...)
+
+	if err != nil {
+		reason := collectorErrorReason(ctx, err)
+		sp.RecordError(err)
+		sp.SetStatus(codes.Error, err.Error())
+
+		if errors.Is(err, rpc.ErrCommandSkipped) {
+			// already logged when the command was rejected first
+			log.Debugf("%s: %v", col.Name(), err)
+		} else {
+			log.Errorf("%s: collector failed on %s%s (%s): %v", col.Name(), device.Host, cols.scopeSuffix(), reason, err)
 		}
 
-		ch <- prometheus.MustNewConstMetric(scrapeCollectorDurationDesc, prometheus.GaugeValue, time.Since(ct).Seconds(), append(l, col.Name())...)
-		sp.End()
+		c.reportResult(device, cols, col.Name(), reason, ch, l)
+		return
+	}
+
+	c.reportResult(device, cols, col.Name(), "", ch, l)
+}
+
+func (c *junosCollector) reportTimeout(device *connector.Device, cols *collectors, collector string, err error, ch chan<- prometheus.Metric, l []string) {
+	log.Warnf("%s: collector did not finish on %s%s: %v", collector, device.Host, cols.scopeSuffix(), err)
+	ch <- prometheus.MustNewConstMetric(scrapeCollectorTimeoutDesc, prometheus.GaugeValue, 1, append(l, collector)...)
+	c.reportResult(device, cols, collector, reasonTimeout, ch, l)
+}
+
+// reportResult sends the success metric and error counters of the collector (reason is empty if the collector succeeded)
+func (c *junosCollector) reportResult(device *connector.Device, cols *collectors, collector string, reason string, ch chan<- prometheus.Metric, l []string) {
+	success := 1.0
+	if reason != "" {
+		success = 0
+		collectorErrors.inc(device.Host, cols.scope(), collector, reason)
 	}
+
+	ch <- prometheus.MustNewConstMetric(collectorSuccessDesc, prometheus.GaugeValue, success, append(l, collector)...)
+	collectorErrors.collect(ch, device.Host, cols.scope(), collector)
 }
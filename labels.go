@@ -0,0 +1,206 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+)
+
+// labelCollisions remembers the collisions of device labels with metric labels already logged (by host and label name)
+var labelCollisions sync.Map
+
+// collectWithLabels sends the metrics collected by collect to ch with labels added to each of them
+func collectWithLabels(labels map[string]string, ch chan<- prometheus.Metric, collect func(ch chan<- prometheus.Metric)) {
+	w := newLabelWrapper(labels)
+	forEachMetric(collect, func(m prometheus.Metric) {
+		ch <- w.wrap(m)
+	})
+}
+
+// collectWithDeviceLabels sends the metrics collected by collect to ch with the labels configured for the device added.
+// Labels already used by a metric (e.g. a dynamic interface label or a label of the collector) are not added to it,
+// so the label of the metric takes precedence. Such collisions are logged once per device and label.
+func collectWithDeviceLabels(host string, labels map[string]string, ch chan<- prometheus.Metric, collect func(ch chan<- prometheus.Metric)) {
+	all := newLabelWrapper(labels)
+
+	// the label names of a metric are defined by its descriptor, so collisions are only checked for the first metric of each descriptor
+	wrappers := make(map[*prometheus.Desc]*labelWrapper)
+	forEachMetric(collect, func(m prometheus.Metric) {
+		desc := m.Desc()
+		w, found := wrappers[desc]
+		if !found {
+			w = all
+			if reduced := labelsWithoutCollisions(host, labels, m); len(reduced) != len(labels) {
+				w = newLabelWrapper(reduced)
+			}
+
+			wrappers[desc] = w
+		}
+
+		ch <- w.wrap(m)
+	})
+}
+
+// forEachMetric calls f for each metric collected by collect
+func forEachMetric(collect func(ch chan<- prometheus.Metric), f func(m prometheus.Metric)) {
+	metrics := make(chan prometheus.Metric)
+	go func() {
+		collect(metrics)
+		close(metrics)
+	}()
+
+	for m := range metrics {
+		f(m)
+	}
+}
+
+// labelsWithoutCollisions returns the labels not used by the metric
+func labelsWithoutCollisions(host string, labels map[string]string, m prometheus.Metric) map[string]string {
+	pb := &dto.Metric{}
+	if m.Write(pb) != nil {
+		// invalid metrics are reported on gathering
+		return labels
+	}
+
+	var reduced map[string]string
+	for _, l := range pb.Label {
+		if _, found := labels[l.GetName()]; !found {
+			continue
+		}
+
+		if reduced == nil {
+			reduced = maps.Clone(labels)
+		}
+		delete(reduced, l.GetName())
+
+		if _, logged := labelCollisions.LoadOrStore(host+"|"+l.GetName(), struct{}{}); !logged {
+			log.Warnf("%s: label %q configured for the device is already used by metrics of the device and is not added to them", host, l.GetName())
+		}
+	}
+
+	if reduced == nil {
+		return labels
+	}
+
+	return reduced
+}
+
+// labelWrapper adds labels to metrics. The descriptors of the labeled metrics are created once per descriptor of the
+// metrics wrapped (prometheus.WrapRegistererWith creates a new one whenever Desc is called), so it must not be shared between goroutines.
+type labelWrapper struct {
+	labels map[string]string
+	pairs  []*dto.LabelPair
+	descs  map[*prometheus.Desc]*prometheus.Desc
+}
+
+func newLabelWrapper(labels map[string]string) *labelWrapper {
+	w := &labelWrapper{
+		labels: labels,
+		pairs:  make([]*dto.LabelPair, 0, len(labels)),
+		descs:  make(map[*prometheus.Desc]*prometheus.Desc),
+	}
+
+	for name, value := range labels {
+		w.pairs = append(w.pairs, &dto.LabelPair{Name: proto.String(name), Value: proto.String(value)})
+	}
+
+	return w
+}
+
+// wrap returns the metric with the labels added
+func (w *labelWrapper) wrap(m prometheus.Metric) prometheus.Metric {
+	if len(w.labels) == 0 {
+		return m
+	}
+
+	desc, found := w.descs[m.Desc()]
+	if !found {
+		desc = wrapDesc(m.Desc(), w.labels)
+		w.descs[m.Desc()] = desc
+	}
+
+	return &labeledMetric{Metric: m, desc: desc, labels: w.pairs}
+}
+
+// wrapDesc returns the descriptor with the labels added as constant labels (as done by prometheus.WrapRegistererWith)
+func wrapDesc(desc *prometheus.Desc, labels map[string]string) *prometheus.Desc {
+	r := &capturingRegisterer{}
+	prometheus.WrapRegistererWith(labels, r).MustRegister(describerFunc(func(ch chan<- *prometheus.Desc) {
+		ch <- desc
+	}))
+
+	ch := make(chan *prometheus.Desc, 1)
+	r.collector.Describe(ch)
+
+	return <-ch
+}
+
+// labeledMetric is a metric with labels added to it
+type labeledMetric struct {
+	prometheus.Metric
+	desc   *prometheus.Desc
+	labels []*dto.LabelPair
+}
+
+// Desc implements prometheus.Metric interface
+func (m *labeledMetric) Desc() *prometheus.Desc {
+	return m.desc
+}
+
+// Write implements prometheus.Metric interface
+func (m *labeledMetric) Write(out *dto.Metric) error {
+	err := m.Metric.Write(out)
+	if err != nil {
+		return err
+	}
+
+	out.Label = append(out.Label, m.labels...)
+	slices.SortFunc(out.Label, func(a, b *dto.LabelPair) int {
+		return strings.Compare(a.GetName(), b.GetName())
+	})
+
+	return nil
+}
+
+// describerFunc is a collector describing the descriptors sent by the function (and collecting no metrics)
+type describerFunc func(ch chan<- *prometheus.Desc)
+
+// Describe implements prometheus.Collector interface
+func (f describerFunc) Describe(ch chan<- *prometheus.Desc) {
+	f(ch)
+}
+
+// Collect implements prometheus.Collector interface
+func (f describerFunc) Collect(ch chan<- prometheus.Metric) {
+}
+
+// capturingRegisterer keeps the collector registered to it, so the wrapping done by prometheus.WrapRegistererWith can be used without a registry
+type capturingRegisterer struct {
+	collector prometheus.Collector
+}
+
+// Register implements prometheus.Registerer interface
+func (r *capturingRegisterer) Register(c prometheus.Collector) error {
+	r.collector = c
+	return nil
+}
+
+// MustRegister implements prometheus.Registerer interface
+func (r *capturingRegisterer) MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		r.Register(c)
+	}
+}
+
+// Unregister implements prometheus.Registerer interface
+func (r *capturingRegisterer) Unregister(c prometheus.Collector) bool {
+	return false
+}
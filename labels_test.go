@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectWithLabels(t *testing.T) {
+	desc := prometheus.NewDesc("junos_test", "Test metric", []string{"target"}, nil)
+
+	ch := make(chan prometheus.Metric, 1)
+	collectWithLabels(map[string]string{"site": "fra"}, ch, func(ch chan<- prometheus.Metric) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, "router1")
+	})
+	close(ch)
+
+	m := <-ch
+	assert.Contains(t, m.Desc().String(), `constLabels: {site="fra"}`)
+
+	pb := &dto.Metric{}
+	assert.NoError(t, m.Write(pb))
+
+	labels := make(map[string]string)
+	for _, l := range pb.Label {
+		labels[l.GetName()] = l.GetValue()
+	}
+	assert.Equal(t, map[string]string{"site": "fra", "target": "router1"}, labels)
+}
+
+func TestCollectWithDeviceLabels(t *testing.T) {
+	desc := prometheus.NewDesc("junos_test", "Test metric", []string{"target", "site"}, nil)
+	other := prometheus.NewDesc("junos_other", "Other metric", []string{"target"}, nil)
+
+	ch := make(chan prometheus.Metric, 2)
+	collectWithDeviceLabels("router1", map[string]string{"site": "fra", "role": "edge"}, ch, func(ch chan<- prometheus.Metric) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, "router1", "ber")
+		ch <- prometheus.MustNewConstMetric(other, prometheus.GaugeValue, 1, "router1")
+	})
+	close(ch)
+
+	labels := make([]map[string]string, 0)
+	for m := range ch {
+		pb := &dto.Metric{}
+		assert.NoError(t, m.Write(pb))
+
+		l := make(map[string]string)
+		for _, lp := range pb.Label {
+			l[lp.GetName()] = lp.GetValue()
+		}
+		labels = append(labels, l)
+	}
+
+	assert.ElementsMatch(t, []map[string]string{
+		{"target": "router1", "site": "ber", "role": "edge"},
+		{"target": "router1", "site": "fra", "role": "edge"},
+	}, labels, "label of the metric takes precedence")
+}
+
+func TestCollectWithDeviceLabelsChecksCollisionsOncePerDescriptor(t *testing.T) {
+	desc := prometheus.NewDesc("junos_test", "Test metric", []string{"target", "name"}, nil)
+
+	writes := 0
+	ch := make(chan prometheus.Metric, 3)
+	collectWithDeviceLabels("router1", map[string]string{"site": "fra"}, ch, func(ch chan<- prometheus.Metric) {
+		for _, name := range []string{"xe-0/0/0", "xe-0/0/1", "xe-0/0/2"} {
+			ch <- &countingMetric{Metric: prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, "router1", name), writes: &writes}
+		}
+	})
+	close(ch)
+
+	descs := make([]*prometheus.Desc, 0)
+	for m := range ch {
+		descs = append(descs, m.Desc())
+	}
+
+	assert.Equal(t, 1, writes)
+	assert.Len(t, descs, 3)
+	assert.Same(t, descs[0], descs[1], "descriptor of the labeled metrics is reused")
+	assert.Same(t, descs[0], descs[2], "descriptor of the labeled metrics is reused")
+}
+
+type countingMetric struct {
+	prometheus.Metric
+	writes *int
+}
+
+func (m *countingMetric) Write(out *dto.Metric) error {
+	*m.writes++
+	return m.Metric.Write(out)
+}
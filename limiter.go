@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// collectorLimiter limits the number of collectors running concurrently.
+// Each running collector uses its own SSH (or NETCONF) session, so the limits apply to the sessions opened per device and in total.
+type collectorLimiter struct {
+	global  chan struct{}
+	devices map[string]chan struct{}
+	mu      sync.Mutex
+}
+
+// newCollectorLimiter creates a limiter allowing max collectors to run at once across all devices (0 = unlimited)
+func newCollectorLimiter(max int) *collectorLimiter {
+	l := &collectorLimiter{
+		devices: make(map[string]chan struct{}),
+	}
+
+	if max > 0 {
+		l.global = make(chan struct{}, max)
+	}
+
+	return l
+}
+
+// acquire blocks until a collector is allowed to run on the device or the context is done.
+// The returned function has to be called when the collector finished.
+func (l *collectorLimiter) acquire(ctx context.Context, host string, limit int) (func(), error) {
+	device := l.deviceSemaphore(host, limit)
+
+	select {
+	case device <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if l.global == nil {
+		return func() { <-device }, nil
+	}
+
+	select {
+	case l.global <- struct{}{}:
+	case <-ctx.Done():
+		<-device
+		return nil, ctx.Err()
+	}
+
+	return func() {
+		<-l.global
+		<-device
+	}, nil
+}
+
+func (l *collectorLimiter) deviceSemaphore(host string, limit int) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	sem, found := l.devices[host]
+	if !found {
+		sem = make(chan struct{}, max(limit, 1))
+		l.devices[host] = sem
+	}
+
+	return sem
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectorLimiterDeviceLimit(t *testing.T) {
+	l := newCollectorLimiter(0)
+
+	release1, err := l.acquire(t.Context(), "router1", 2)
+	assert.NoError(t, err)
+
+	release2, err := l.acquire(t.Context(), "router1", 2)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = l.acquire(ctx, "router1", 2)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "device limit should be enforced")
+
+	release3, err := l.acquire(t.Context(), "router2", 2)
+	assert.NoError(t, err, "limit should be applied per device")
+
+	release1()
+	release4, err := l.acquire(t.Context(), "router1", 2)
+	assert.NoError(t, err, "slot should be available after release")
+
+	release2()
+	release3()
+	release4()
+}
+
+func TestCollectorLimiterGlobalLimit(t *testing.T) {
+	l := newCollectorLimiter(2)
+
+	release1, err := l.acquire(t.Context(), "router1", 5)
+	assert.NoError(t, err)
+
+	release2, err := l.acquire(t.Context(), "router2", 5)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = l.acquire(ctx, "router3", 5)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "global limit should be enforced")
+
+	release2()
+
+	release3, err := l.acquire(t.Context(), "router1", 5)
+	assert.NoError(t, err, "slot should be available after release")
+
+	_, err = l.acquire(ctx, "router3", 5)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release1()
+	release3()
+
+	// the device slot taken while waiting for the global limit has to be released on failure
+	release, err := l.acquire(t.Context(), "router3", 1)
+	assert.NoError(t, err)
+	release()
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/czerwonk/junos_exporter/pkg/connector"
+	"github.com/czerwonk/junos_exporter/pkg/rpc"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const logicalSystemsCommand = "show configuration logical-systems"
+
+// logicalSystemsDiscovered keeps the logical systems discovered on the devices
+var logicalSystemsDiscovered = &discoveredLogicalSystemsCache{
+	entries: make(map[string]discoveredLogicalSystemsEntry),
+}
+
+type logicalSystemsResult struct {
+	XMLName       xml.Name `xml:"rpc-reply"`
+	Configuration struct {
+		LogicalSystems []struct {
+			Name string `xml:"name"`
+		} `xml:"logical-systems"`
+	} `xml:"configuration"`
+}
+
+// logicalSystemsForDevice returns the logical systems configured for the device and, if enabled, the ones discovered on the device
+func (c *junosCollector) logicalSystemsForDevice(ctx context.Context, device *connector.Device, cl *rpc.Client) []string {
+	dc := c.collectors.cfg.FindDeviceConfig(device.Host)
+	if dc == nil {
+		return nil
+	}
+
+	logicalSystems := slices.Clone(dc.LogicalSystems)
+	if dc.DiscoverLogicalSystems == nil || !*dc.DiscoverLogicalSystems {
+		return logicalSystems
+	}
+
+	discovered, err := discoveredLogicalSystems(ctx, device.Host, cl)
+	if err != nil {
+		log.Errorf("Could not discover logical systems of %s: %v", device.Host, err)
+		return logicalSystems
+	}
+
+	for _, ls := range discovered {
+		if !slices.Contains(logicalSystems, ls) {
+			logicalSystems = append(logicalSystems, ls)
+		}
+	}
+
+	return logicalSystems
+}
+
+// discoveredLogicalSystems returns the names of the logical systems discovered on the device.
+// The names are reused for the discovery interval, so the configuration is not fetched on every scrape.
+func discoveredLogicalSystems(ctx context.Context, host string, cl *rpc.Client) ([]string, error) {
+	if names, found := logicalSystemsDiscovered.get(host); found {
+		return names, nil
+	}
+
+	names, err := discoverLogicalSystems(ctx, cl)
+	if err != nil {
+		return nil, err
+	}
+
+	if *lsDiscoveryInterval > 0 {
+		logicalSystemsDiscovered.set(host, names, *lsDiscoveryInterval)
+	}
+
+	return names, nil
+}
+
+// discoverLogicalSystems returns the names of the logical systems in the configuration of the device
+func discoverLogicalSystems(ctx context.Context, cl *rpc.Client) ([]string, error) {
+	var x logicalSystemsResult
+	err := cl.RunCommandAndParseContext(ctx, logicalSystemsCommand, &x)
+	if err != nil {
+		return nil, err
+	}
+
+	return logicalSystemNames(&x), nil
+}
+
+func logicalSystemNames(x *logicalSystemsResult) []string {
+	names := make([]string, 0, len(x.Configuration.LogicalSystems))
+	for _, ls := range x.Configuration.LogicalSystems {
+		names = append(names, ls.Name)
+	}
+
+	return names
+}
+
+// discoveredLogicalSystemsCache keeps the names of the logical systems discovered by host until they expire
+type discoveredLogicalSystemsCache struct {
+	entries map[string]discoveredLogicalSystemsEntry
+	mu      sync.Mutex
+}
+
+type discoveredLogicalSystemsEntry struct {
+	names   []string
+	expires time.Time
+}
+
+func (c *discoveredLogicalSystemsCache) get(host string) ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, found := c.entries[host]
+	if !found {
+		return nil, false
+	}
+
+	if time.Now().After(e.expires) {
+		delete(c.entries, host)
+		return nil, false
+	}
+
+	return e.names, true
+}
+
+func (c *discoveredLogicalSystemsCache) set(host string, names []string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[host] = discoveredLogicalSystemsEntry{
+		names:   names,
+		expires: time.Now().Add(ttl),
+	}
+}
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogicalSystemNames(t *testing.T) {
+	body := `<rpc-reply xmlns:junos="http://xml.juniper.net/junos/21.4R3/junos">
+    <configuration junos:changed-seconds="1700000000" junos:changed-localtime="2023-11-14 22:13:20 UTC">
+            <logical-systems>
+                <name>LS1</name>
+                <interfaces>
+                    <interface>
+                        <name>lt-0/0/0</name>
+                    </interface>
+                </interfaces>
+            </logical-systems>
+            <logical-systems>
+                <name>LS2</name>
+            </logical-systems>
+    </configuration>
+    <cli>
+        <banner></banner>
+    </cli>
+</rpc-reply>`
+
+	var x logicalSystemsResult
+	err := xml.Unmarshal([]byte(body), &x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"LS1", "LS2"}, logicalSystemNames(&x))
+}
+
+func TestDiscoveredLogicalSystemsCached(t *testing.T) {
+	logicalSystemsDiscovered.set("router1", []string{"LS1", "LS2"}, time.Minute)
+
+	names, err := discoveredLogicalSystems(context.Background(), "router1", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"LS1", "LS2"}, names)
+
+	_, found := responseCache.Get("router1", logicalSystemsCommand)
+	assert.False(t, found, "discovered logical systems are not kept in the response cache")
+}
+
+func TestDiscoveredLogicalSystemsCacheExpires(t *testing.T) {
+	c := &discoveredLogicalSystemsCache{entries: make(map[string]discoveredLogicalSystemsEntry)}
+
+	c.set("router1", []string{"LS1"}, time.Minute)
+	names, found := c.get("router1")
+	assert.True(t, found)
+	assert.Equal(t, []string{"LS1"}, names)
+
+	c.set("router1", []string{"LS1"}, -time.Second)
+	_, found = c.get("router1")
+	assert.False(t, found)
+}
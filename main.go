@@ -10,13 +10,19 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/czerwonk/junos_exporter/internal/config"
+	"github.com/czerwonk/junos_exporter/pkg/cache"
 	"github.com/czerwonk/junos_exporter/pkg/connector"
+	"github.com/czerwonk/junos_exporter/pkg/internalmetrics"
+	"github.com/czerwonk/junos_exporter/pkg/metricprofile"
+	"github.com/czerwonk/junos_exporter/pkg/rpc"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -28,77 +34,61 @@ import (
 const version string = "0.15.0"
 
 var (
-	showVersion                 = flag.Bool("version", false, "Print version information.")
-	listenAddress               = flag.String("web.listen-address", ":9326", "Address on which to expose metrics and web interface.")
-	metricsPath                 = flag.String("web.telemetry-path", "/metrics", "Path under which to expose metrics.")
-	sshHosts                    = flag.String("ssh.targets", "", "Hosts to scrape")
-	sshUsername                 = flag.String("ssh.user", "junos_exporter", "Username to use when connecting to junos devices using ssh")
-	sshKeyFile                  = flag.String("ssh.keyfile", "", "Public key file to use when connecting to junos devices using ssh")
-	sshKeyPassphrase            = flag.String("ssh.keyPassphrase", "", "Passphrase to decrypt key file if it's encrypted")
-	sshPassword                 = flag.String("ssh.password", "", "Password to use when connecting to junos devices using ssh")
-	sshReconnectInterval        = flag.Duration("ssh.reconnect-interval", 30*time.Second, "Duration to wait before reconnecting to a device after connection got lost")
-	sshKeepAliveInterval        = flag.Duration("ssh.keep-alive-interval", 10*time.Second, "Duration to wait between keep alive messages")
-	sshKeepAliveTimeout         = flag.Duration("ssh.keep-alive-timeout", 15*time.Second, "Duration to wait for keep alive message response")
-	sshExpireTimeout            = flag.Duration("ssh.expire-timeout", 15*time.Minute, "Duration after an connection is terminated when it is not used")
-	debug                       = flag.Bool("debug", false, "Show verbose debug output in log")
-	aaaEnabled                  = flag.Bool("aaa.enabled", false, "Scrape AAA metrics")
-	alarmEnabled                = flag.Bool("alarm.enabled", false, "Scrape Alarm metrics")
-	ntpEnabled                  = flag.Bool("ntp.enabled", false, "Scrape NTP metrics")
-	bgpEnabled                  = flag.Bool("bgp.enabled", true, "Scrape BGP metrics")
-	dot1xEnabled                = flag.Bool("dot1x.enabled", false, "Scrape dot1x metrics")
-	ospfEnabled                 = flag.Bool("ospf.enabled", true, "Scrape OSPFv3 metrics")
-	isisEnabled                 = flag.Bool("isis.enabled", false, "Scrape ISIS metrics")
-	l2circuitEnabled            = flag.Bool("l2circuit.enabled", false, "Scrape l2circuit metrics")
-	l2vpnEnabled                = flag.Bool("l2vpn.enabled", false, "Scrape l2vpn metrics")
-	natEnabled                  = flag.Bool("nat.enabled", false, "Scrape NAT metrics")
-	nat2Enabled                 = flag.Bool("nat2.enabled", false, "Scrape NAT2 metrics")
-	ldpEnabled                  = flag.Bool("ldp.enabled", true, "Scrape ldp metrics")
-	routingEngineEnabled        = flag.Bool("routingengine.enabled", true, "Scrape Routing Engine metrics")
-	routesEnabled               = flag.Bool("routes.enabled", true, "Scrape routing table metrics")
-	environmentEnabled          = flag.Bool("environment.enabled", true, "Scrape environment metrics")
-	firewallEnabled             = flag.Bool("firewall.enabled", true, "Scrape Firewall count metrics")
-	interfacesEnabled           = flag.Bool("interfaces.enabled", true, "Scrape interface metrics")
-	interfaceDiagnosticsEnabled = flag.Bool("ifdiag.enabled", true, "Scrape optical interface diagnostic metrics")
-	ipsecEnabled                = flag.Bool("ipsec.enabled", false, "Scrape IPSec metrics")
-	securityEnabled             = flag.Bool("security.enabled", false, "Scrape security metrics")
-	securityPoliciesEnabled     = flag.Bool("security_policies.enabled", false, "Scrape security policy metrics")
-	storageEnabled              = flag.Bool("storage.enabled", true, "Scrape system storage metrics")
-	fpcEnabled                  = flag.Bool("fpc.enabled", true, "Scrape line card metrics")
-	accountingEnabled           = flag.Bool("accounting.enabled", false, "Scrape accounting flow metrics")
-	interfaceQueuesEnabled      = flag.Bool("queues.enabled", false, "Scrape interface queue metrics")
-	rpkiEnabled                 = flag.Bool("rpki.enabled", false, "Scrape rpki metrics")
-	satelliteEnabled            = flag.Bool("satellite.enabled", false, "Scrape metrics from satellite devices")
-	systemEnabled               = flag.Bool("system.enabled", false, "Scrape system metrics")
-	macEnabled                  = flag.Bool("mac.enabled", false, "Scrape MAC address table metrics")
-	alarmFilter                 = flag.String("alarms.filter", "", "Regex to filter for alerts to ignore")
-	configFile                  = flag.String("config.file", "", "Path to config file")
-	dynamicIfaceLabels          = flag.Bool("dynamic-interface-labels", true, "Parse interface descriptions to get labels dynamically")
-	interfaceDescriptionRegex   = flag.String("interface-description-regex", "", "give a regex to retrieve the interface description labels")
-	lsEnabled                   = flag.Bool("logical-systems.enabled", false, "Enable logical systems support")
-	powerEnabled                = flag.Bool("power.enabled", true, "Scrape power metrics")
-	lacpEnabled                 = flag.Bool("lacp.enabled", false, "Scrape LACP metrics")
-	bfdEnabled                  = flag.Bool("bfd.enabled", false, "Scrape BFD metrics")
-	vpwsEnabled                 = flag.Bool("vpws.enabled", false, "Scrape EVPN VPWS metrics")
-	mplsLSPEnabled              = flag.Bool("mpls_lsp.enabled", false, "Scrape MPLS LSP metrics")
-	licenseEnabled              = flag.Bool("license.enabled", false, "Scrape license metrics")
-	tlsEnabled                  = flag.Bool("tls.enabled", false, "Enables TLS")
-	tlsCertChainPath            = flag.String("tls.cert-file", "", "Path to TLS cert file")
-	tlsKeyPath                  = flag.String("tls.key-file", "", "Path to TLS key file")
-	tracingEnabled              = flag.Bool("tracing.enabled", false, "Enables tracing using OpenTelemetry")
-	tracingProvider             = flag.String("tracing.provider", "", "Sets the tracing provider (stdout or collector)")
-	tracingCollectorEndpoint    = flag.String("tracing.collector.grpc-endpoint", "", "Sets the tracing provider (stdout or collector)")
-	subscriberEnabled           = flag.Bool("subscriber.enabled", false, "Scrape subscribers detail")
-	macsecEnabled               = flag.Bool("macsec.enabled", true, "Scrape MACSec metrics")
-	arpEnabled                  = flag.Bool("arps.enabled", true, "Scrape ARP metrics")
-	poeEnabled                  = flag.Bool("poe.enabled", true, "Scrape PoE metrics")
-	krtEnabled                  = flag.Bool("krt.enabled", false, "Scrape KRT queue metrics")
-	twampEnabled                = flag.Bool("twamp.enabled", false, "Scrape TWAMP metrics")
-	systemstatisticsEnabled     = flag.Bool("systemstatistics.enabled", true, "Scrape system statistics metrics")
-	cfg                         *config.Config
-	devices                     []*connector.Device
-	connManager                 *connector.SSHConnectionManager
-	reloadCh                    chan chan error
-	configMu                    sync.RWMutex
+	showVersion               = flag.Bool("version", false, "Print version information.")
+	listenAddress             = flag.String("web.listen-address", ":9326", "Address on which to expose metrics and web interface.")
+	metricsPath               = flag.String("web.telemetry-path", "/metrics", "Path under which to expose metrics.")
+	exporterMetricsPath       = flag.String("web.exporter-telemetry-path", "/exporter-metrics", "Path under which to expose metrics of the exporter itself (connections, commands, Go runtime).")
+	scrapeTimeoutOffset       = flag.Duration("web.scrape-timeout-offset", 500*time.Millisecond, "Offset to subtract from the scrape timeout sent by Prometheus (X-Prometheus-Scrape-Timeout-Seconds) to finish the scrape in time")
+	sshHosts                  = flag.String("ssh.targets", "", "Hosts to scrape")
+	sshUsername               = flag.String("ssh.user", "junos_exporter", "Username to use when connecting to junos devices using ssh")
+	sshKeyFile                = flag.String("ssh.keyfile", "", "Public key file to use when connecting to junos devices using ssh")
+	sshKeyPassphrase          = flag.String("ssh.keyPassphrase", "", "Passphrase to decrypt key file if it's encrypted")
+	sshPassword               = flag.String("ssh.password", "", "Password to use when connecting to junos devices using ssh")
+	sshCertFile               = flag.String("ssh.certfile", "", "OpenSSH certificate file to use for certificate based authentication (default: <keyfile>-cert.pub)")
+	sshAuthMethods            = flag.String("ssh.auth-methods", "", "Comma separated list of authentication methods to try in order (key, certificate, agent, password, keyboard-interactive)")
+	sshKnownHostsFile         = flag.String("ssh.known-hosts-file", "", "OpenSSH known_hosts file to verify the host keys of the devices with")
+	sshHostKeyTOFU            = flag.Bool("ssh.host-key-trust-on-first-use", false, "Accept host keys of unknown devices and add them to the known_hosts file")
+	sshProxy                  = flag.String("ssh.proxy", "", "URL of the proxy to connect to devices through (socks5://, socks5h:// or http://, default: ALL_PROXY environment variable)")
+	sshReconnectInterval      = flag.Duration("ssh.reconnect-interval", 30*time.Second, "Duration to wait before reconnecting to a device marked down (doubled after every failed reconnect)")
+	sshMaxReconnectInterval   = flag.Duration("ssh.max-reconnect-interval", 10*time.Minute, "Maximum duration to wait before reconnecting to a device marked down")
+	sshFailureThreshold       = flag.Int("ssh.failure-threshold", 3, "Number of consecutive failed connection attempts after a device is marked down")
+	sshKeepAliveInterval      = flag.Duration("ssh.keep-alive-interval", 10*time.Second, "Duration to wait between keep alive messages")
+	sshKeepAliveTimeout       = flag.Duration("ssh.keep-alive-timeout", 15*time.Second, "Duration to wait for keep alive message response")
+	sshExpireTimeout          = flag.Duration("ssh.expire-timeout", 15*time.Minute, "Duration after an connection is terminated when it is not used")
+	sshConnectTimeout         = flag.Duration("ssh.connect-timeout", 5*time.Second, "Duration to wait for a connection to a device to be established")
+	sshCommandTimeout         = flag.Duration("ssh.command-timeout", 0, "Duration to wait for the output of a command before it is aborted (0 = until the scrape times out)")
+	collectorConcurrency      = flag.Int("collectors.concurrency", 1, "Number of collectors running concurrently on a device (each collector uses its own SSH or NETCONF session)")
+	collectorMaxConcurrency   = flag.Int("collectors.max-concurrency", 0, "Maximum number of collectors running concurrently across all devices (0 = unlimited)")
+	metricsProfile            = flag.String("metrics.profile", metricprofile.V1, "Naming and typing of the exported metrics (v1 or v2 exporting cumulative values as counters with _total suffix in base units)")
+	unsupportedRetryInterval  = flag.Duration("unsupported-commands.retry-interval", time.Hour, "Duration after which commands rejected by a device as not supported are tried again (they are always tried again after an upgrade)")
+	pollingInterval           = flag.Duration("polling.interval", 0, "Interval to poll devices in the background and serve metrics from the last poll instead of scraping on request (0 = disabled)")
+	debug                     = flag.Bool("debug", false, "Show verbose debug output in log")
+	listCollectors            = flag.Bool("collectors.list", false, "Print the available collectors (key, feature, flag, default and options) and exit")
+	featureFlags              = registerFeatureFlags()
+	alarmFilter               = flag.String("alarms.filter", "", "Regex to filter for alerts to ignore")
+	configFile                = flag.String("config.file", "", "Path to config file")
+	dynamicIfaceLabels        = flag.Bool("dynamic-interface-labels", true, "Parse interface descriptions to get labels dynamically")
+	interfaceDescriptionRegex = flag.String("interface-description-regex", "", "give a regex to retrieve the interface description labels")
+	lsEnabled                 = flag.Bool("logical-systems.enabled", false, "Enable logical systems support")
+	lsDiscoveryInterval       = flag.Duration("logical-systems.discovery-interval", 10*time.Minute, "Duration to reuse the logical systems discovered on a device for (0 = discover on every scrape)")
+	tlsEnabled                = flag.Bool("tls.enabled", false, "Enables TLS")
+	tlsCertChainPath          = flag.String("tls.cert-file", "", "Path to TLS cert file")
+	tlsKeyPath                = flag.String("tls.key-file", "", "Path to TLS key file")
+	tracingEnabled            = flag.Bool("tracing.enabled", false, "Enables tracing using OpenTelemetry")
+	tracingProvider           = flag.String("tracing.provider", "", "Sets the tracing provider (stdout or collector)")
+	tracingCollectorEndpoint  = flag.String("tracing.collector.grpc-endpoint", "", "Sets the tracing provider (stdout or collector)")
+	cfg                       *config.Config
+	devices                   []*connector.Device
+	connManager               *connector.SSHConnectionManager
+	collectorLimits           *collectorLimiter
+	devicePoller              *poller
+	scrapes                   = newScrapeCoalescer()
+	responseCache             = cache.New()
+	collectorErrors           = newCollectorErrorCounter()
+	unsupportedCommands       *rpc.UnsupportedCommands
+	metricConverter           = metricprofile.NewConverter()
+	reloadCh                  chan chan error
+	configMu                  sync.RWMutex
 )
 
 func init() {
@@ -117,6 +107,13 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *listCollectors {
+		printCollectors(os.Stdout)
+		os.Exit(0)
+	}
+
+	unsupportedCommands = rpc.NewUnsupportedCommands(*unsupportedRetryInterval)
+
 	err := initialize()
 	if err != nil {
 		log.Fatalf("could not initialize exporter. %v", err)
@@ -183,11 +180,21 @@ func printVersion() {
 }
 
 func initialize() error {
+	err := metricprofile.Validate(*metricsProfile)
+	if err != nil {
+		return err
+	}
+
 	c, err := loadConfig()
 	if err != nil {
 		return err
 	}
 
+	err = validateCustomCollectors(c)
+	if err != nil {
+		return err
+	}
+
 	devices, err = devicesForConfig(c)
 	if err != nil {
 		return err
@@ -195,14 +202,24 @@ func initialize() error {
 	cfg = c
 
 	connManager = connectionManager()
+	collectorLimits = newCollectorLimiter(*collectorMaxConcurrency)
+	devicePoller = startPoller(devices, cfg)
 
 	return nil
 }
 
 func reinitialize() error {
+	if devicePoller != nil {
+		// stopped before acquiring the lock to abort running polls holding a read lock
+		// (requests still see the stopped poller serving the last snapshots until it is replaced)
+		devicePoller.stop()
+	}
+
 	configMu.Lock()
 	defer configMu.Unlock()
 
+	devicePoller = nil
+
 	if connManager != nil {
 		connManager.CloseAll()
 		connManager = nil
@@ -230,59 +247,31 @@ func loadConfigFromFlags() *config.Config {
 	c.Targets = strings.Split(*sshHosts, ",")
 	c.LSEnabled = *lsEnabled
 	c.IfDescReStr = *interfaceDescriptionRegex
+	c.KnownHostsFile = *sshKnownHostsFile
+	c.HostKeyTOFU = *sshHostKeyTOFU
+	c.Proxy = *sshProxy
+	c.Concurrency = *collectorConcurrency
+	if *sshAuthMethods != "" {
+		c.AuthMethods = strings.Split(*sshAuthMethods, ",")
+	}
 
 	f := &c.Features
-	f.AAA = *aaaEnabled
-	f.Alarm = *alarmEnabled
-	f.NTP = *ntpEnabled
-	f.BGP = *bgpEnabled
-	f.DOT1X = *dot1xEnabled
-	f.Environment = *environmentEnabled
-	f.Firewall = *firewallEnabled
-	f.Interfaces = *interfacesEnabled
-	f.InterfaceDiagnostic = *interfaceDiagnosticsEnabled
-	f.InterfaceQueue = *interfaceQueuesEnabled
-	f.IPSec = *ipsecEnabled
-	f.Security = *securityEnabled
-	f.SecurityPolicies = *securityPoliciesEnabled
-	f.ISIS = *isisEnabled
-	f.NAT = *natEnabled
-	f.NAT2 = *nat2Enabled
-	f.OSPF = *ospfEnabled
-	f.LDP = *ldpEnabled
-	f.L2Circuit = *l2circuitEnabled
-	f.L2Vpn = *l2vpnEnabled
-	f.Routes = *routesEnabled
-	f.RoutingEngine = *routingEngineEnabled
-	f.Accounting = *accountingEnabled
-	f.FPC = *fpcEnabled
-	f.RPKI = *rpkiEnabled
-	f.Storage = *storageEnabled
-	f.Satellite = *satelliteEnabled
-	f.System = *systemEnabled
-	f.Power = *powerEnabled
-	f.MAC = *macEnabled
-	f.LACP = *lacpEnabled
-	f.BFD = *bfdEnabled
-	f.VPWS = *vpwsEnabled
-	f.MPLSLSP = *mplsLSPEnabled
-	f.License = *licenseEnabled
-	f.Subscriber = *subscriberEnabled
-	f.MACSec = *macsecEnabled
-	f.ARP = *arpEnabled
-	f.Poe = *poeEnabled
-	f.KRT = *krtEnabled
-	f.TWAMP = *twampEnabled
-	f.SystemStatistics = *systemstatisticsEnabled
+	for feature, enabled := range featureFlags {
+		f.Set(feature, *enabled)
+	}
+
 	return c
 }
 
 func connectionManager() *connector.SSHConnectionManager {
 	opts := []connector.Option{
 		connector.WithReconnectInterval(*sshReconnectInterval),
+		connector.WithMaxReconnectInterval(*sshMaxReconnectInterval),
+		connector.WithFailureThreshold(*sshFailureThreshold),
 		connector.WithKeepAliveInterval(*sshKeepAliveInterval),
 		connector.WithKeepAliveTimeout(*sshKeepAliveTimeout),
 		connector.WithExpiredConnectionTimeout(*sshExpireTimeout),
+		connector.WithConnectTimeout(*sshConnectTimeout),
 	}
 
 	return connector.NewConnectionManager(opts...)
@@ -296,13 +285,16 @@ func startServer() {
 			<body>
 			<h1>JunOS Exporter</h1>
 			<p><a href="` + *metricsPath + `">Metrics</a></p>
+			<p><a href="` + *exporterMetricsPath + `">Exporter Metrics</a></p>
 			<h2>More information:</h2>
 			<p><a href="https://github.com/czerwonk/junos_exporter">github.com/czerwonk/junos_exporter</a></p>
 			</body>
 			</html>`))
 	})
 	http.HandleFunc(*metricsPath, handleMetricsRequest)
+	http.Handle(*exporterMetricsPath, promhttp.HandlerFor(internalmetrics.Registry, promhttp.HandlerOpts{}))
 	http.HandleFunc("/-/reload", updateConfiguration)
+	http.HandleFunc("/-/cache/invalidate", invalidateCache)
 
 	log.Infof("Listening for %s on %s (TLS: %v)", *metricsPath, *listenAddress, *tlsEnabled)
 	if *tlsEnabled {
@@ -327,6 +319,24 @@ func updateConfiguration(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// invalidateCache removes the cached responses of the target passed as parameter (or of all targets if not set)
+func invalidateCache(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST method expected", 400)
+		return
+	}
+
+	target := r.URL.Query().Get("target")
+	if target == "" {
+		log.Infoln("Invalidating cached responses of all targets")
+		responseCache.InvalidateAll()
+		return
+	}
+
+	log.Infof("Invalidating cached responses of %s", target)
+	responseCache.Invalidate(target)
+}
+
 func handleMetricsRequest(w http.ResponseWriter, r *http.Request) {
 	configMu.RLock()
 	defer configMu.RUnlock()
@@ -334,6 +344,15 @@ func handleMetricsRequest(w http.ResponseWriter, r *http.Request) {
 	ctx, span := tracer.Start(r.Context(), "HandleMetricsRequest")
 	defer span.End()
 
+	ctx, cancel, err := contextWithScrapeTimeout(ctx, r)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	defer cancel()
+
 	reg := prometheus.NewRegistry()
 
 	devs, err := devicesForRequest(r)
@@ -353,8 +372,52 @@ func handleMetricsRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	c := newJunosCollector(ctx, devs, logicalSystem)
-	reg.MustRegister(c)
+	moduleNames := moduleNamesForRequest(r)
+	var module *config.ModuleConfig
+	if len(moduleNames) > 0 {
+		module, err = cfg.ModuleForNames(moduleNames)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			http.Error(w, err.Error(), 400)
+			return
+		}
+	}
+
+	collect := r.URL.Query()["collect[]"]
+	err = validateCollectorKeys(collect, cfg)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	if logicalSystem == "" && module == nil && len(collect) == 0 {
+		var polled []*connector.Device
+		p := devicePoller
+		devs, polled = splitPolledDevices(devs, p)
+		if len(polled) > 0 {
+			reg.MustRegister(&snapshotCollector{poller: p, devices: polled})
+		}
+	}
+
+	if len(devs) > 0 {
+		// concurrent requests for the same target, logical system, modules and collectors share one collection
+		key := r.URL.Query().Get("target") + "|" + logicalSystem + "|" + strings.Join(moduleNames, ",") + "|" + strings.Join(collect, ",")
+		metrics, shared := scrapes.do(key, func() []prometheus.Metric {
+			ctx, cancel := detachedContext(ctx)
+			defer cancel()
+
+			return collectMetrics(newJunosCollector(ctx, devs, logicalSystem, module, collect))
+		})
+
+		if shared {
+			internalmetrics.CoalescedScrapes.Inc()
+		}
+
+		reg.MustRegister(&metricsCollector{metrics: metrics})
+	}
 
 	l := log.New()
 	l.Level = log.ErrorLevel
@@ -365,6 +428,56 @@ func handleMetricsRequest(w http.ResponseWriter, r *http.Request) {
 	}).ServeHTTP(w, r)
 }
 
+// contextWithScrapeTimeout sets the deadline of the scrape using the timeout sent by Prometheus minus the configured offset
+func contextWithScrapeTimeout(ctx context.Context, r *http.Request) (context.Context, context.CancelFunc, error) {
+	v := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds")
+	if v == "" {
+		ctx, cancel := context.WithCancel(ctx)
+		return ctx, cancel, nil
+	}
+
+	seconds, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid scrape timeout %q: %w", v, err)
+	}
+
+	timeout := time.Duration(seconds*float64(time.Second)) - *scrapeTimeoutOffset
+	if timeout <= 0 {
+		return nil, nil, fmt.Errorf("scrape timeout %q is less than the configured offset (%s)", v, *scrapeTimeoutOffset)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, nil
+}
+
+// moduleNamesForRequest returns the names of the modules to scrape (module parameter, multiple modules can be passed as list or comma separated)
+func moduleNamesForRequest(r *http.Request) []string {
+	names := make([]string, 0)
+	for _, v := range r.URL.Query()["module"] {
+		for _, name := range strings.Split(v, ",") {
+			name = strings.TrimSpace(name)
+			if name != "" && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names
+}
+
+// splitPolledDevices separates the devices polled in the background from the ones to scrape on request
+func splitPolledDevices(devs []*connector.Device, p *poller) (live []*connector.Device, polled []*connector.Device) {
+	for _, d := range devs {
+		if p.isPolled(d.Host) {
+			polled = append(polled, d)
+		} else {
+			live = append(live, d)
+		}
+	}
+
+	return live, polled
+}
+
 func devicesForRequest(r *http.Request) ([]*connector.Device, error) {
 	reqTarget := r.URL.Query().Get("target")
 	if reqTarget == "" {
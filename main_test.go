@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextWithScrapeTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		expected    time.Duration
+		hasDeadline bool
+		wantErr     bool
+	}{
+		{
+			name: "no header",
+		},
+		{
+			name:        "timeout minus offset",
+			header:      "10",
+			expected:    9500 * time.Millisecond,
+			hasDeadline: true,
+		},
+		{
+			name:        "fractional seconds",
+			header:      "2.5",
+			expected:    2 * time.Second,
+			hasDeadline: true,
+		},
+		{
+			name:    "timeout less than offset",
+			header:  "0.2",
+			wantErr: true,
+		},
+		{
+			name:    "invalid value",
+			header:  "abc",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/metrics", nil)
+			if test.header != "" {
+				r.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", test.header)
+			}
+
+			start := time.Now()
+			ctx, cancel, err := contextWithScrapeTimeout(t.Context(), r)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			defer cancel()
+
+			assert.NoError(t, err)
+
+			deadline, ok := ctx.Deadline()
+			assert.Equal(t, test.hasDeadline, ok)
+			if ok {
+				assert.WithinDuration(t, start.Add(test.expected), deadline, 100*time.Millisecond)
+			}
+		})
+	}
+}
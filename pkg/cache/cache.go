@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: MIT
+
+// Package cache caches command responses of devices for data changing rarely (e.g. inventory or license information)
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type entry struct {
+	b       []byte
+	expires time.Time
+}
+
+// Cache stores command responses by device and command
+type Cache struct {
+	devices map[string]map[string]*entry
+	mu      sync.RWMutex
+}
+
+// New creates a new empty cache
+func New() *Cache {
+	return &Cache{
+		devices: make(map[string]map[string]*entry),
+	}
+}
+
+// Get returns the cached response of the command for the device, if present and not expired
+func (c *Cache) Get(host, cmd string) ([]byte, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e, found := c.devices[host][cmd]
+	if !found || time.Now().After(e.expires) {
+		return nil, false
+	}
+
+	return e.b, true
+}
+
+// Set stores the response of the command for the device for the given duration
+func (c *Cache) Set(host, cmd string, b []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	entries, found := c.devices[host]
+	if !found {
+		entries = make(map[string]*entry)
+		c.devices[host] = entries
+	}
+
+	for k, e := range entries {
+		if now.After(e.expires) {
+			delete(entries, k)
+		}
+	}
+
+	entries[cmd] = &entry{
+		b:       b,
+		expires: now.Add(ttl),
+	}
+}
+
+// Invalidate removes all cached responses of the device
+func (c *Cache) Invalidate(host string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.devices, host)
+}
+
+// InvalidateAll removes all cached responses
+func (c *Cache) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.devices = make(map[string]map[string]*entry)
+}
@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: MIT
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/connector"
+	"github.com/czerwonk/junos_exporter/pkg/rpc"
+)
+
+func TestCache(t *testing.T) {
+	c := New()
+
+	c.Set("router1", "show chassis hardware", []byte("hardware"), time.Minute)
+	c.Set("router1", "show system information", []byte("info"), -time.Second)
+	c.Set("router2", "show chassis hardware", []byte("hardware2"), time.Minute)
+
+	b, found := c.Get("router1", "show chassis hardware")
+	assert.True(t, found)
+	assert.Equal(t, "hardware", string(b))
+
+	_, found = c.Get("router1", "show system information")
+	assert.False(t, found, "expired entries should not be returned")
+
+	_, found = c.Get("router1", "show version")
+	assert.False(t, found)
+
+	c.Invalidate("router1")
+	_, found = c.Get("router1", "show chassis hardware")
+	assert.False(t, found, "entries of invalidated target should be removed")
+
+	_, found = c.Get("router2", "show chassis hardware")
+	assert.True(t, found, "entries of other targets should be kept")
+
+	c.InvalidateAll()
+	_, found = c.Get("router2", "show chassis hardware")
+	assert.False(t, found)
+}
+
+func TestClient(t *testing.T) {
+	cl := &fakeClient{
+		responses: map[string]string{
+			"show chassis hardware": "<chassis>mx960</chassis>",
+			"show interfaces":       "<interfaces/>",
+			"show version":          "<invalid",
+		},
+	}
+
+	c := NewClient(cl, New(), func(cmd string) time.Duration {
+		if cmd == "show interfaces" {
+			return 0
+		}
+
+		return time.Minute
+	})
+
+	var x struct {
+		Chassis string `xml:"chassis"`
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, c.RunCommandAndParse("show chassis hardware", &x))
+		assert.NoError(t, c.RunCommandAndParse("show interfaces", &struct{}{}))
+		assert.Error(t, c.RunCommandAndParse("show version", &struct{}{}))
+	}
+
+	assert.Equal(t, 1, cl.calls["show chassis hardware"], "cached command should be sent once")
+	assert.Equal(t, 3, cl.calls["show interfaces"], "command without TTL should not be cached")
+	assert.Equal(t, 3, cl.calls["show version"], "responses failing to parse should not be cached")
+}
+
+type fakeClient struct {
+	collector.Client
+	responses map[string]string
+	calls     map[string]int
+}
+
+func (c *fakeClient) RunCommandAndParseWithParser(cmd string, parser rpc.Parser) error {
+	if c.calls == nil {
+		c.calls = make(map[string]int)
+	}
+	c.calls[cmd]++
+
+	return parser([]byte(c.responses[cmd]))
+}
+
+func (c *fakeClient) Device() *connector.Device {
+	return &connector.Device{Host: "router1"}
+}
+
+func (c *fakeClient) Context() context.Context {
+	return context.Background()
+}
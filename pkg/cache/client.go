@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: MIT
+
+package cache
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/internalmetrics"
+	"github.com/czerwonk/junos_exporter/pkg/rpc"
+)
+
+// TTLFunc returns the duration to cache the response of a command for (0 disables caching for the command)
+type TTLFunc func(cmd string) time.Duration
+
+// Client wraps a collector.Client serving responses of commands with a TTL from the cache
+type Client struct {
+	collector.Client
+	cache *Cache
+	ttl   TTLFunc
+}
+
+// NewClient creates a caching client for cl
+func NewClient(cl collector.Client, cache *Cache, ttl TTLFunc) *Client {
+	return &Client{
+		Client: cl,
+		cache:  cache,
+		ttl:    ttl,
+	}
+}
+
+// RunCommandAndParse implements RunCommandAndParse of the collector.Client interface
+func (c *Client) RunCommandAndParse(cmd string, obj interface{}) error {
+	return c.RunCommandAndParseWithParser(cmd, func(b []byte) error {
+		return xml.Unmarshal(b, obj)
+	})
+}
+
+// RunCommandAndParseWithParser implements RunCommandAndParseWithParser of the collector.Client interface
+func (c *Client) RunCommandAndParseWithParser(cmd string, parser rpc.Parser) error {
+	ttl := c.ttl(cmd)
+	if ttl <= 0 {
+		return c.Client.RunCommandAndParseWithParser(cmd, parser)
+	}
+
+	host := c.Device().Host
+	collectorName := rpc.CollectorFromContext(c.Context())
+
+	if b, found := c.cache.Get(host, cmd); found {
+		internalmetrics.CacheHits.WithLabelValues(collectorName).Inc()
+
+		err := parser(b)
+		if err != nil {
+			return &rpc.ParseError{Command: cmd, Err: err}
+		}
+
+		return nil
+	}
+
+	internalmetrics.CacheMisses.WithLabelValues(collectorName).Inc()
+
+	return c.Client.RunCommandAndParseWithParser(cmd, func(b []byte) error {
+		err := parser(b)
+		if err != nil {
+			// do not cache responses we are not able to parse
+			return err
+		}
+
+		c.cache.Set(host, cmd, b, ttl)
+		return nil
+	})
+}
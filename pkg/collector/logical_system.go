@@ -0,0 +1,12 @@
+// SPDX-License-Identifier: MIT
+
+package collector
+
+// LogicalSystemCommand returns the command to run cmd in the logical system (cmd if no logical system is set)
+func LogicalSystemCommand(cmd, logicalSystem string) string {
+	if logicalSystem == "" {
+		return cmd
+	}
+
+	return cmd + " logical-system " + logicalSystem
+}
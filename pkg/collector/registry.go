@@ -0,0 +1,217 @@
+// SPDX-License-Identifier: MIT
+
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
+	"github.com/czerwonk/junos_exporter/pkg/routinginstance"
+)
+
+// Default defines whether a collector is enabled if not configured otherwise
+type Default int
+
+const (
+	// Disabled collectors have to be enabled explicitly
+	Disabled Default = 0
+
+	// EnabledInConfig collectors are enabled unless disabled in the config file
+	EnabledInConfig Default = 1
+
+	// EnabledByFlag collectors are enabled unless disabled by their flag (used when no config file is set)
+	EnabledByFlag Default = 2
+
+	// Enabled collectors are enabled unless disabled in the config file or by their flag
+	Enabled = EnabledInConfig | EnabledByFlag
+)
+
+// Option is an option a collector can be created with
+type Option string
+
+const (
+	// OptionLogicalSystem is the logical system to run the commands in.
+	// Collectors supporting it are run for each logical system of a device.
+	OptionLogicalSystem Option = "logical_system"
+
+	// OptionRoutingInstance is the routing instance to run the commands in.
+	// Collectors supporting it are run for each routing instance of a device (fan out).
+	OptionRoutingInstance Option = "routing_instance"
+
+	// OptionRoutingInstanceFilter selects the routing instances to export metrics for
+	OptionRoutingInstanceFilter Option = "routing_instance_filter"
+
+	// OptionInterfaceDescriptionRegex is the regex to parse labels from interface descriptions with
+	OptionInterfaceDescriptionRegex Option = "interface_description_regex"
+
+	// OptionInterfaceFilter selects the interfaces to export metrics for
+	OptionInterfaceFilter Option = "interface_filter"
+
+	// OptionAlarmFilter is the regex of alarms to ignore
+	OptionAlarmFilter Option = "alarm_filter"
+)
+
+// Options are the values of the options a collector is created with.
+// Only the options declared in the registration of the collector are set.
+type Options struct {
+	LogicalSystem             string
+	RoutingInstance           string
+	RoutingInstanceFilter     *routinginstance.Filter
+	InterfaceDescriptionRegex *regexp.Regexp
+	InterfaceFilter           *interfacefilter.Filter
+	AlarmFilter               string
+}
+
+// Features provides the enablement of features (e.g. by config file or module)
+type Features interface {
+	// Enabled returns if the feature with the given key is enabled
+	Enabled(feature string) bool
+}
+
+// Registration describes a collector available in the exporter
+type Registration struct {
+	// Key identifies the collector (e.g. in the collect[] parameter, the cache config and collector errors)
+	Key string
+
+	// Feature is the key enabling the collector in the features section of the config file (default: Key)
+	Feature string
+
+	// EnabledBy are further features enabling the collector (e.g. license for the system collector)
+	EnabledBy []string
+
+	// Flag is the name of the flag enabling the collector if no config file is used (default: <Feature>.enabled)
+	Flag string
+
+	// Description describes the metrics of the collector (e.g. "BGP metrics")
+	Description string
+
+	// Default defines whether the collector is enabled if not configured otherwise
+	Default Default
+
+	// Options are the options the collector supports
+	Options []Option
+
+	// New creates the collector
+	New func(opts Options) RPCCollector
+}
+
+var (
+	registry   = make(map[string]*Registration)
+	registryMu sync.RWMutex
+)
+
+// Register makes a collector available in the exporter. It is meant to be called in the init function of the
+// package implementing the collector and panics if the registration is invalid or conflicts with another one.
+func Register(r *Registration) {
+	if r.Key == "" || r.New == nil {
+		panic("collector: key and constructor are required")
+	}
+
+	if r.Feature == "" {
+		r.Feature = r.Key
+	}
+
+	if r.Flag == "" {
+		r.Flag = r.Feature + ".enabled"
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, other := range registry {
+		if other.Key == r.Key || other.Feature == r.Feature || other.Flag == r.Flag {
+			panic(fmt.Sprintf("collector: registration of %s conflicts with %s", r.Key, other.Key))
+		}
+	}
+
+	registry[r.Key] = r
+}
+
+// Registrations returns all registered collectors sorted by key
+func Registrations() []*Registration {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	regs := make([]*Registration, 0, len(registry))
+	for _, r := range registry {
+		regs = append(regs, r)
+	}
+
+	slices.SortFunc(regs, func(a, b *Registration) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return regs
+}
+
+// Keys returns the sorted keys of all registered collectors
+func Keys() []string {
+	keys := make([]string, 0)
+	for _, r := range Registrations() {
+		keys = append(keys, r.Key)
+	}
+
+	return keys
+}
+
+// ForFeature returns the registration of the collector enabled by the feature (nil if there is none)
+func ForFeature(feature string) *Registration {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for _, r := range registry {
+		if r.Feature == feature {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// Supports returns if the collector supports the option
+func (r *Registration) Supports(o Option) bool {
+	return slices.Contains(r.Options, o)
+}
+
+// EnabledFor returns if the collector is enabled by the features
+func (r *Registration) EnabledFor(f Features) bool {
+	if f.Enabled(r.Feature) {
+		return true
+	}
+
+	for _, feature := range r.EnabledBy {
+		if f.Enabled(feature) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Collector creates the collector passing only the options it supports
+func (r *Registration) Collector(opts Options) RPCCollector {
+	o := Options{}
+
+	for _, opt := range r.Options {
+		switch opt {
+		case OptionLogicalSystem:
+			o.LogicalSystem = opts.LogicalSystem
+		case OptionRoutingInstance:
+			o.RoutingInstance = opts.RoutingInstance
+		case OptionRoutingInstanceFilter:
+			o.RoutingInstanceFilter = opts.RoutingInstanceFilter
+		case OptionInterfaceDescriptionRegex:
+			o.InterfaceDescriptionRegex = opts.InterfaceDescriptionRegex
+		case OptionInterfaceFilter:
+			o.InterfaceFilter = opts.InterfaceFilter
+		case OptionAlarmFilter:
+			o.AlarmFilter = opts.AlarmFilter
+		}
+	}
+
+	return r.New(o)
+}
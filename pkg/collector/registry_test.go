@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: MIT
+
+package collector
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+	Register(&Registration{
+		Key:         "registry_test",
+		Description: "test metrics",
+		New: func(Options) RPCCollector {
+			return &testCollector{}
+		},
+	})
+
+	r := ForFeature("registry_test")
+	if assert.NotNil(t, r) {
+		assert.Equal(t, "registry_test", r.Feature, "feature defaults to key")
+		assert.Equal(t, "registry_test.enabled", r.Flag, "flag defaults to feature")
+	}
+	assert.Contains(t, Keys(), "registry_test")
+
+	assert.Panics(t, func() {
+		Register(&Registration{
+			Key: "registry_test",
+			New: func(Options) RPCCollector {
+				return &testCollector{}
+			},
+		})
+	}, "duplicate key")
+	assert.Panics(t, func() {
+		Register(&Registration{
+			Key:  "registry_test2",
+			Flag: "registry_test.enabled",
+			New: func(Options) RPCCollector {
+				return &testCollector{}
+			},
+		})
+	}, "duplicate flag")
+	assert.Panics(t, func() {
+		Register(&Registration{Key: "registry_test3"})
+	}, "missing constructor")
+}
+
+func TestRegistrationCollector(t *testing.T) {
+	var got Options
+	r := &Registration{
+		Key:     "test",
+		Options: []Option{OptionLogicalSystem, OptionInterfaceDescriptionRegex},
+		New: func(opts Options) RPCCollector {
+			got = opts
+			return &testCollector{}
+		},
+	}
+
+	re := regexp.MustCompile(".*")
+	r.Collector(Options{
+		LogicalSystem:             "ls1",
+		RoutingInstance:           "CUST-A",
+		InterfaceDescriptionRegex: re,
+		AlarmFilter:               "foo",
+	})
+
+	assert.Equal(t, Options{LogicalSystem: "ls1", InterfaceDescriptionRegex: re}, got, "only supported options are passed")
+	assert.True(t, r.Supports(OptionLogicalSystem))
+	assert.False(t, r.Supports(OptionRoutingInstance))
+}
+
+func TestRegistrationEnabledFor(t *testing.T) {
+	r := &Registration{
+		Key:       "system",
+		Feature:   "system",
+		EnabledBy: []string{"license"},
+	}
+
+	assert.False(t, r.EnabledFor(testFeatures{}))
+	assert.True(t, r.EnabledFor(testFeatures{"system": true}))
+	assert.True(t, r.EnabledFor(testFeatures{"license": true}))
+}
+
+type testFeatures map[string]bool
+
+func (f testFeatures) Enabled(feature string) bool {
+	return f[feature]
+}
+
+type testCollector struct{}
+
+func (c *testCollector) Name() string {
+	return "test"
+}
+
+func (c *testCollector) Describe(ch chan<- *prometheus.Desc) {
+}
+
+func (c *testCollector) Collect(client Client, ch chan<- prometheus.Metric, labelValues []string) error {
+	return nil
+}
@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: MIT
+
+package connector
+
+import (
+	"io"
+	"net"
+	"os"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// AuthConfig collects the authentication methods offered to the device. It is built on every connect.
+// The SSH client tries each authentication method (e.g. "publickey") only once, so signers of
+// keys, certificates and the agent are offered by a single public key method.
+type AuthConfig struct {
+	User string
+	Auth []ssh.AuthMethod
+
+	signerSources []signerSource
+	closers       []io.Closer
+}
+
+// signerSource provides the signers for public key authentication
+type signerSource func() ([]ssh.Signer, error)
+
+// AuthByCertificate uses public key authentication with an OpenSSH certificate signed by a CA.
+// Key and certificate are read from the files on every connect, so renewed (short-lived) certificates are picked up.
+func AuthByCertificate(username, keyFile, certFile, keyPassphrase string) (AuthMethod, error) {
+	load := func() ([]ssh.Signer, error) {
+		signer, err := loadCertificateFiles(keyFile, certFile, keyPassphrase)
+		if err != nil {
+			return nil, err
+		}
+
+		return []ssh.Signer{signer}, nil
+	}
+
+	// fail early on invalid files
+	_, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	return authBySigners(username, load), nil
+}
+
+// AuthByAgent uses public key authentication with keys provided by the SSH agent listening on socket (e.g. SSH_AUTH_SOCK)
+func AuthByAgent(username, socket string) AuthMethod {
+	return func(cfg *AuthConfig) {
+		cfg.User = username
+		cfg.addSigners(func() ([]ssh.Signer, error) {
+			conn, err := net.Dial("unix", socket)
+			if err != nil {
+				return nil, errors.Wrap(err, "could not connect to SSH agent")
+			}
+
+			cfg.closers = append(cfg.closers, conn)
+			return agent.NewClient(conn).Signers()
+		})
+	}
+}
+
+// AuthByKeyboardInteractive uses keyboard-interactive authentication answering the password prompt(s)
+func AuthByKeyboardInteractive(username, password string) AuthMethod {
+	return func(cfg *AuthConfig) {
+		cfg.User = username
+		cfg.Auth = append(cfg.Auth, ssh.KeyboardInteractive(func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+			answers := make([]string, len(questions))
+			for i := range questions {
+				// prompts with echo enabled do not ask for secrets
+				if !echos[i] {
+					answers[i] = password
+				}
+			}
+
+			return answers, nil
+		}))
+	}
+}
+
+// ChainAuth combines multiple authentication methods which are offered to the device in the given order.
+// Public key based methods (key, certificate and agent) are merged into one public key method at the position of the first one.
+func ChainAuth(methods ...AuthMethod) AuthMethod {
+	return func(cfg *AuthConfig) {
+		for _, m := range methods {
+			m(cfg)
+		}
+	}
+}
+
+func authBySigners(username string, source signerSource) AuthMethod {
+	return func(cfg *AuthConfig) {
+		cfg.User = username
+		cfg.addSigners(source)
+	}
+}
+
+func loadCertificateFiles(keyFile, certFile, keyPassphrase string) (ssh.Signer, error) {
+	key, err := os.Open(keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open ssh key file")
+	}
+	defer key.Close()
+
+	cert, err := os.Open(certFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open ssh certificate file")
+	}
+	defer cert.Close()
+
+	pk, err := loadPrivateKey(key, keyPassphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadCertificate(cert, pk)
+}
+
+// addSigners adds the source to the public key method, which is added at the position of the first public key based method
+func (cfg *AuthConfig) addSigners(source signerSource) {
+	if len(cfg.signerSources) == 0 {
+		cfg.Auth = append(cfg.Auth, ssh.PublicKeysCallback(cfg.signers))
+	}
+
+	cfg.signerSources = append(cfg.signerSources, source)
+}
+
+func (cfg *AuthConfig) signers() ([]ssh.Signer, error) {
+	var lastErr error
+	signers := make([]ssh.Signer, 0)
+	for _, src := range cfg.signerSources {
+		s, err := src()
+		if err != nil {
+			// try the remaining sources
+			log.Warnf("could not get signers for public key authentication: %v", err)
+			lastErr = err
+			continue
+		}
+
+		signers = append(signers, s...)
+	}
+
+	if len(signers) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+
+	return signers, nil
+}
+
+// close frees resources allocated for authentication (e.g. agent connections) after the handshake has finished
+func (cfg *AuthConfig) close() {
+	for _, c := range cfg.closers {
+		c.Close()
+	}
+
+	cfg.closers = nil
+}
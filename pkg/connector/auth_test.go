@@ -0,0 +1,202 @@
+// SPDX-License-Identifier: MIT
+
+package connector
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestChainAuthMergesPublicKeyMethods(t *testing.T) {
+	ca, _ := generateSigner(t)
+	_, unknownKeyPEM := generateSigner(t)
+	userKey, userKeyPEM := generateSigner(t)
+
+	cert := signCertificate(t, ca, userKey, ssh.CertTimeInfinity)
+
+	checker := &ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			return bytes.Equal(auth.Marshal(), ca.PublicKey().Marshal())
+		},
+	}
+	serverCfg := &ssh.ServerConfig{
+		PublicKeyCallback: checker.Authenticate,
+	}
+
+	keyAuth, err := AuthByKey("exporter", bytes.NewReader(unknownKeyPEM), "")
+	assert.NoError(t, err)
+
+	keyFile, certFile := writeCertificateFiles(t, userKeyPEM, cert)
+	certAuth, err := AuthByCertificate("exporter", keyFile, certFile, "")
+	assert.NoError(t, err)
+
+	err = handshake(t, serverCfg, ChainAuth(keyAuth, AuthByPassword("exporter", "wrong"), certAuth))
+	assert.NoError(t, err)
+}
+
+func TestAuthByCertificateReloadsRenewedCertificate(t *testing.T) {
+	ca, _ := generateSigner(t)
+	userKey, userKeyPEM := generateSigner(t)
+
+	checker := &ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			return bytes.Equal(auth.Marshal(), ca.PublicKey().Marshal())
+		},
+	}
+	serverCfg := &ssh.ServerConfig{
+		PublicKeyCallback: checker.Authenticate,
+	}
+
+	expired := signCertificate(t, ca, userKey, uint64(time.Now().Add(-time.Minute).Unix()))
+	keyFile, certFile := writeCertificateFiles(t, userKeyPEM, expired)
+
+	auth, err := AuthByCertificate("exporter", keyFile, certFile, "")
+	assert.NoError(t, err)
+
+	err = handshake(t, serverCfg, auth)
+	assert.Error(t, err, "expired certificate")
+
+	renewed := signCertificate(t, ca, userKey, uint64(time.Now().Add(time.Hour).Unix()))
+	err = os.WriteFile(certFile, ssh.MarshalAuthorizedKey(renewed), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = handshake(t, serverCfg, auth)
+	assert.NoError(t, err, "renewed certificate")
+}
+
+func TestAuthByKeyboardInteractive(t *testing.T) {
+	serverCfg := &ssh.ServerConfig{
+		KeyboardInteractiveCallback: func(conn ssh.ConnMetadata, challenge ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+			answers, err := challenge("", "", []string{"Username: ", "Password: "}, []bool{true, false})
+			if err != nil {
+				return nil, err
+			}
+
+			if conn.User() != "exporter" || answers[1] != "secret" {
+				return nil, errors.New("access denied")
+			}
+
+			return &ssh.Permissions{}, nil
+		},
+	}
+
+	err := handshake(t, serverCfg, AuthByKeyboardInteractive("exporter", "secret"))
+	assert.NoError(t, err)
+
+	err = handshake(t, serverCfg, AuthByKeyboardInteractive("exporter", "wrong"))
+	assert.Error(t, err)
+}
+
+func handshake(t *testing.T, serverCfg *ssh.ServerConfig, auth AuthMethod) error {
+	hostKey, _ := generateSigner(t)
+	serverCfg.AddHostKey(hostKey)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go func() {
+		serverConn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer serverConn.Close()
+
+		conn, _, _, err := ssh.NewServerConn(serverConn, serverCfg)
+		if err == nil {
+			conn.Close()
+		}
+	}()
+
+	clientConn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientConn.Close()
+
+	authCfg := &AuthConfig{}
+	auth(authCfg)
+	defer authCfg.close()
+
+	cfg := &ssh.ClientConfig{
+		User:            authCfg.User,
+		Auth:            authCfg.Auth,
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}
+
+	conn, _, _, err := ssh.NewClientConn(clientConn, "router1:22", cfg)
+	if err != nil {
+		return err
+	}
+
+	conn.Close()
+	return nil
+}
+
+func signCertificate(t *testing.T, ca ssh.Signer, key ssh.Signer, validBefore uint64) *ssh.Certificate {
+	cert := &ssh.Certificate{
+		Key:             key.PublicKey(),
+		CertType:        ssh.UserCert,
+		ValidPrincipals: []string{"exporter"},
+		ValidBefore:     validBefore,
+	}
+
+	err := cert.SignCert(rand.Reader, ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert
+}
+
+func writeCertificateFiles(t *testing.T, keyPEM []byte, cert *ssh.Certificate) (string, string) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "id_ed25519")
+	certFile := keyFile + "-cert.pub"
+
+	err := os.WriteFile(keyFile, keyPEM, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(certFile, ssh.MarshalAuthorizedKey(cert), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return keyFile, certFile
+}
+
+func generateSigner(t *testing.T) (ssh.Signer, []byte) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signer, pem.EncodeToMemory(block)
+}
@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: MIT
+
+package connector
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of the circuit breaker of a device
+type BreakerState int
+
+const (
+	// BreakerClosed allows connections to the device
+	BreakerClosed BreakerState = iota
+
+	// BreakerHalfOpen allows a single probe connection to the device, other attempts fail fast
+	BreakerHalfOpen
+
+	// BreakerOpen marks the device down, connection attempts fail fast until the next retry is allowed
+	BreakerOpen
+)
+
+// String implements the fmt.Stringer interface
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerHalfOpen:
+		return "half-open"
+	case BreakerOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// DeviceDownError is returned when connecting to a device marked down by its circuit breaker
+type DeviceDownError struct {
+	Host      string
+	Failures  int
+	NextRetry time.Time
+}
+
+// Error implements the error interface
+func (e *DeviceDownError) Error() string {
+	return fmt.Sprintf("device %s is marked down after %d failed connection attempts, next retry at %s", e.Host, e.Failures, e.NextRetry.Format(time.RFC3339))
+}
+
+// circuitBreaker tracks failed connection attempts to a device.
+// After threshold consecutive failures the breaker opens and attempts fail fast until the backoff elapsed.
+// The backoff doubles with every failed probe (starting at interval, capped at maxInterval) and is jittered
+// to avoid reconnecting to many devices at once.
+type circuitBreaker struct {
+	host        string
+	threshold   int
+	interval    time.Duration
+	maxInterval time.Duration
+	state       BreakerState
+	failures    int
+	nextRetry   time.Time
+	mu          sync.Mutex
+}
+
+func newCircuitBreaker(host string, threshold int, interval, maxInterval time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		host:        host,
+		threshold:   threshold,
+		interval:    interval,
+		maxInterval: maxInterval,
+	}
+}
+
+// allow returns an error if no connection attempt is allowed at the moment
+func (b *circuitBreaker) allow(now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if now.Before(b.nextRetry) {
+			return b.downError()
+		}
+
+		b.state = BreakerHalfOpen
+		return nil
+	case BreakerHalfOpen:
+		// a probe is already in progress
+		return b.downError()
+	default:
+		return nil
+	}
+}
+
+func (b *circuitBreaker) downError() error {
+	return &DeviceDownError{
+		Host:      b.host,
+		Failures:  b.failures,
+		NextRetry: b.nextRetry,
+	}
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.nextRetry = time.Time{}
+}
+
+func (b *circuitBreaker) failure(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.failures < b.threshold {
+		return
+	}
+
+	b.state = BreakerOpen
+	b.nextRetry = now.Add(b.backoff())
+}
+
+// backoff returns the jittered duration to wait before the next probe (between half and full exponential backoff)
+func (b *circuitBreaker) backoff() time.Duration {
+	d := b.interval
+	for i := b.threshold; i < b.failures && d < b.maxInterval; i++ {
+		d *= 2
+	}
+
+	if d > b.maxInterval {
+		d = b.maxInterval
+	}
+
+	if d <= 1 {
+		return d
+	}
+
+	return d/2 + rand.N(d/2)
+}
+
+func (b *circuitBreaker) status() (BreakerState, time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state, b.nextRetry
+}
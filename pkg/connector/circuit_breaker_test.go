@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: MIT
+
+package connector
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	b := newCircuitBreaker("router1", 3, 10*time.Second, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, b.allow(now))
+		b.failure(now)
+	}
+
+	state, _ := b.status()
+	assert.Equal(t, BreakerClosed, state, "breaker should be closed below the failure threshold")
+	assert.NoError(t, b.allow(now))
+
+	b.failure(now)
+	state, nextRetry := b.status()
+	assert.Equal(t, BreakerOpen, state)
+	assert.GreaterOrEqual(t, nextRetry.Sub(now), 5*time.Second)
+	assert.LessOrEqual(t, nextRetry.Sub(now), 10*time.Second)
+
+	var downErr *DeviceDownError
+	assert.True(t, errors.As(b.allow(now), &downErr), "attempts should fail fast while open")
+	assert.Equal(t, 3, downErr.Failures)
+
+	assert.NoError(t, b.allow(nextRetry), "probe should be allowed after backoff")
+	assert.Error(t, b.allow(nextRetry), "only one probe should be allowed while half-open")
+
+	state, _ = b.status()
+	assert.Equal(t, BreakerHalfOpen, state)
+
+	b.failure(nextRetry)
+	state, retry := b.status()
+	assert.Equal(t, BreakerOpen, state)
+	assert.GreaterOrEqual(t, retry.Sub(nextRetry), 10*time.Second, "backoff should double after failed probe")
+	assert.LessOrEqual(t, retry.Sub(nextRetry), 20*time.Second)
+
+	assert.NoError(t, b.allow(retry))
+	b.success()
+
+	state, retry = b.status()
+	assert.Equal(t, BreakerClosed, state)
+	assert.True(t, retry.IsZero())
+}
+
+func TestCircuitBreakerMaxInterval(t *testing.T) {
+	b := newCircuitBreaker("router1", 1, 10*time.Second, time.Minute)
+	b.failures = 100
+
+	for i := 0; i < 100; i++ {
+		d := b.backoff()
+		assert.GreaterOrEqual(t, d, 30*time.Second)
+		assert.LessOrEqual(t, d, time.Minute)
+	}
+}
+
+func TestConnectionManagerMarksDeviceDown(t *testing.T) {
+	router := newTestSSHServer(t, func(cmd string) string { return "router: " + cmd })
+
+	var dials atomic.Int32
+	var unreachable atomic.Bool
+	unreachable.Store(true)
+
+	d := &Device{
+		Host: router.Addr(),
+		Auth: AuthByPassword("exporter", "secret"),
+		Dialer: dialerFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+			dials.Add(1)
+			if unreachable.Load() {
+				return nil, errors.New("no route to host")
+			}
+
+			return (&net.Dialer{}).DialContext(ctx, network, address)
+		}),
+	}
+
+	m := NewConnectionManager(
+		WithFailureThreshold(2),
+		WithReconnectInterval(100*time.Millisecond),
+		WithMaxReconnectInterval(time.Second),
+	)
+	defer m.CloseAll()
+
+	for i := 0; i < 2; i++ {
+		_, err := m.GetSSHConnection(d)
+		assert.Error(t, err)
+	}
+
+	_, err := m.GetSSHConnection(d)
+	var downErr *DeviceDownError
+	assert.True(t, errors.As(err, &downErr), "expected device down error, got: %v", err)
+	assert.Equal(t, int32(2), dials.Load(), "device marked down should not be dialed")
+
+	state, nextRetry := m.BreakerStatus(d)
+	assert.Equal(t, BreakerOpen, state)
+	assert.True(t, nextRetry.After(time.Now()))
+
+	unreachable.Store(false)
+	assert.Eventually(t, func() bool {
+		_, err := m.GetSSHConnection(d)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond, "device should be reconnected after backoff")
+
+	state, nextRetry = m.BreakerStatus(d)
+	assert.Equal(t, BreakerClosed, state)
+	assert.True(t, nextRetry.IsZero())
+}
+
+type dialerFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+func (f dialerFunc) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return f(ctx, network, address)
+}
@@ -4,15 +4,19 @@ package connector
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/czerwonk/junos_exporter/pkg/internalmetrics"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 )
 
 // SSHConnection encapsulates the connection to the device
@@ -27,14 +31,78 @@ type SSHConnection struct {
 	done              chan struct{}
 	keepAliveInterval time.Duration
 	keepAliveTimeout  time.Duration
+	connectTimeout    time.Duration
+	netconfIdle       []*netconfSession // NETCONF sessions not used by a running RPC
+	netconfMu         sync.Mutex        // protects netconfIdle
+	dial              dialFunc
+	parent            *SSHConnection
+	dependents        map[*SSHConnection]struct{}
+	dependentsMu      sync.Mutex
 }
 
+// dialFunc opens the transport connection to the device
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
 func NewSSHConnection(device *Device, keepAliveInterval time.Duration, keepAliveTimeout time.Duration) *SSHConnection {
-	return &SSHConnection{
+	c := &SSHConnection{
 		device:            device,
 		keepAliveInterval: keepAliveInterval,
 		keepAliveTimeout:  keepAliveTimeout,
+		connectTimeout:    timeoutInSeconds * time.Second,
 		done:              make(chan struct{}),
+		dial:              (&net.Dialer{}).DialContext,
+		dependents:        make(map[*SSHConnection]struct{}),
+	}
+
+	if device.Dialer != nil {
+		c.dial = device.Dialer.DialContext
+	}
+
+	return c
+}
+
+// tunnelThrough establishes the connection through the SSH connection of a jump host (using direct-tcpip channels)
+func (c *SSHConnection) tunnelThrough(jump *SSHConnection) {
+	c.parent = jump
+	c.dial = jump.dialThrough
+}
+
+func (c *SSHConnection) dialThrough(ctx context.Context, network, address string) (net.Conn, error) {
+	sshClient := c.getSSHClient()
+	if sshClient == nil {
+		return nil, fmt.Errorf("no SSH client to jump host %s", c.device.Host)
+	}
+
+	c.setLastUsed(time.Now())
+
+	return sshClient.DialContext(ctx, network, address)
+}
+
+func (c *SSHConnection) addDependent(dep *SSHConnection) {
+	c.dependentsMu.Lock()
+	defer c.dependentsMu.Unlock()
+
+	c.dependents[dep] = struct{}{}
+}
+
+func (c *SSHConnection) removeDependent(dep *SSHConnection) {
+	c.dependentsMu.Lock()
+	defer c.dependentsMu.Unlock()
+
+	delete(c.dependents, dep)
+}
+
+// stopDependents stops all connections tunneled through this connection
+func (c *SSHConnection) stopDependents() {
+	c.dependentsMu.Lock()
+	deps := make([]*SSHConnection, 0, len(c.dependents))
+	for dep := range c.dependents {
+		deps = append(deps, dep)
+	}
+	c.dependentsMu.Unlock()
+
+	for _, dep := range deps {
+		dep.Stop(fmt.Errorf("connection to jump host %s lost", c.device.Host))
 	}
 }
 
@@ -44,6 +112,10 @@ func (c *SSHConnection) Start(expiredConnectionTimeout time.Duration) error {
 		return err
 	}
 
+	if c.parent != nil {
+		c.parent.addDependent(c)
+	}
+
 	go c.keepalive(expiredConnectionTimeout)
 	return nil
 }
@@ -51,15 +123,29 @@ func (c *SSHConnection) Start(expiredConnectionTimeout time.Duration) error {
 func (c *SSHConnection) Stop(err error) {
 	log.Infof("Stopping SSH connection with %s (reason: %v)", c.device.Host, err)
 
+	if !c.close() {
+		return
+	}
+
+	if c.parent != nil {
+		c.parent.removeDependent(c)
+	}
+
+	c.stopDependents()
+}
+
+func (c *SSHConnection) close() bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	if !c.isConnected {
-		return
+		return false
 	}
 
 	close(c.done)
 
+	c.closeNetconfSessions()
+
 	if c.sshClient != nil {
 		c.sshClient.Close()
 		c.sshClient = nil
@@ -71,29 +157,56 @@ func (c *SSHConnection) Stop(err error) {
 	}
 
 	c.isConnected = false
+	internalmetrics.ActiveConnections.Dec()
+
+	return true
 }
 
 // RunCommand runs a command against the device
 func (c *SSHConnection) RunCommand(cmd string) ([]byte, error) {
+	return c.RunCommandContext(context.Background(), cmd)
+}
+
+// RunCommandContext runs a command against the device.
+// If the context is done before the command finished, the session is signalled and closed.
+func (c *SSHConnection) RunCommandContext(ctx context.Context, cmd string) ([]byte, error) {
 	c.setLastUsed(time.Now())
 
+	err := ctx.Err()
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not run command %q on %s", cmd, c.device.Host)
+	}
+
 	sshClient := c.getSSHClient()
 	if sshClient == nil {
 		c.Stop(fmt.Errorf("No ssh client"))
 		return nil, errors.New(fmt.Sprintf("no SSH client to %s", c.device.Host))
 	}
 
-	session, err := c.sshClient.NewSession()
+	session, err := sshClient.NewSession()
 	if err != nil {
+		internalmetrics.SessionOpenFailures.WithLabelValues(c.device.Host).Inc()
 		c.Stop(fmt.Errorf("SSH session failure"))
 		return nil, errors.Wrapf(err, "could not open session with %s", c.device.Host)
 	}
 	defer session.Close()
 
+	stop := context.AfterFunc(ctx, func() {
+		log.Warnf("Aborting command %q on %s: %v", cmd, c.device.Host, ctx.Err())
+		_ = session.Signal(ssh.SIGKILL)
+		session.Close()
+	})
+	defer stop()
+
 	var b = &bytes.Buffer{}
 	session.Stdout = b
 
 	err = session.Run(cmd)
+	if ctx.Err() != nil {
+		// the connection is still usable, only the session was aborted
+		return nil, errors.Wrapf(ctx.Err(), "command %q on %s aborted", cmd, c.device.Host)
+	}
+
 	if err != nil {
 		c.Stop(fmt.Errorf("failed running command"))
 		return nil, errors.Wrapf(err, "could not run command %q on %s", cmd, c.device.Host)
@@ -102,6 +215,98 @@ func (c *SSHConnection) RunCommand(cmd string) ([]byte, error) {
 	return b.Bytes(), nil
 }
 
+// RunNetconfRPC sends an RPC to the device using a NETCONF session of the connection and returns the rpc-reply.
+// NETCONF sessions are established on demand, one per concurrently running RPC, and kept open for the lifetime of the connection.
+func (c *SSHConnection) RunNetconfRPC(rpc string) ([]byte, error) {
+	return c.RunNetconfRPCContext(context.Background(), rpc)
+}
+
+// RunNetconfRPCContext sends an RPC to the device using a NETCONF session of the connection and returns the rpc-reply.
+// If the context is done before the reply was received, the NETCONF session is aborted and reestablished on next use.
+// The connection itself is kept.
+func (c *SSHConnection) RunNetconfRPCContext(ctx context.Context, rpc string) ([]byte, error) {
+	c.setLastUsed(time.Now())
+
+	for {
+		err := ctx.Err()
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not run NETCONF RPC on %s", c.device.Host)
+		}
+
+		s, err := c.getNetconfSession()
+		if err != nil {
+			internalmetrics.SessionOpenFailures.WithLabelValues(c.device.Host).Inc()
+			c.Stop(fmt.Errorf("NETCONF session failure"))
+			return nil, errors.Wrapf(err, "could not open NETCONF session with %s", c.device.Host)
+		}
+
+		b, err := s.exec(ctx, rpc)
+		if err != nil && ctx.Err() != nil {
+			log.Warnf("Aborted NETCONF RPC on %s: %v", c.device.Host, ctx.Err())
+			return nil, errors.Wrapf(ctx.Err(), "NETCONF RPC on %s aborted", c.device.Host)
+		}
+
+		if errors.Is(err, errNetconfSessionAborted) {
+			// the session was aborted by the RPC running before, the next attempt uses a new session
+			continue
+		}
+
+		if err != nil {
+			c.Stop(fmt.Errorf("failed running NETCONF RPC"))
+			return nil, errors.Wrapf(err, "could not run NETCONF RPC on %s", c.device.Host)
+		}
+
+		c.putNetconfSession(s)
+		return b, nil
+	}
+}
+
+// getNetconfSession returns an idle NETCONF session of the connection or establishes a new one,
+// so RPCs running concurrently (e.g. collectors of a device with concurrency > 1) do not wait for each other.
+func (c *SSHConnection) getNetconfSession() (*netconfSession, error) {
+	sshClient := c.getSSHClient()
+	if sshClient == nil {
+		return nil, errors.New("no SSH client")
+	}
+
+	c.netconfMu.Lock()
+	for len(c.netconfIdle) > 0 {
+		s := c.netconfIdle[len(c.netconfIdle)-1]
+		c.netconfIdle = c.netconfIdle[:len(c.netconfIdle)-1]
+
+		if s.client == sshClient && !s.aborted.Load() {
+			c.netconfMu.Unlock()
+			return s, nil
+		}
+	}
+	c.netconfMu.Unlock()
+
+	return newNetconfSession(sshClient)
+}
+
+// putNetconfSession makes the session available to the next RPC. Aborted sessions and sessions of a previous SSH client are dropped.
+func (c *SSHConnection) putNetconfSession(s *netconfSession) {
+	if s.aborted.Load() || s.client != c.getSSHClient() {
+		return
+	}
+
+	c.netconfMu.Lock()
+	defer c.netconfMu.Unlock()
+
+	c.netconfIdle = append(c.netconfIdle, s)
+}
+
+func (c *SSHConnection) closeNetconfSessions() {
+	c.netconfMu.Lock()
+	defer c.netconfMu.Unlock()
+
+	for _, s := range c.netconfIdle {
+		s.close()
+	}
+
+	c.netconfIdle = nil
+}
+
 func (c *SSHConnection) keepalive(expiredConnectionTimeout time.Duration) {
 	for {
 		select {
@@ -111,7 +316,13 @@ func (c *SSHConnection) keepalive(expiredConnectionTimeout time.Duration) {
 				return
 			}
 
-			_ = c.tcpConn.SetDeadline(time.Now().Add(c.keepAliveTimeout))
+			tcpConn := c.getTCPConn()
+			if tcpConn == nil {
+				// connection was stopped concurrently
+				return
+			}
+
+			_ = tcpConn.SetDeadline(time.Now().Add(c.keepAliveTimeout))
 
 			ok := c.testSSHClient()
 			if !ok {
@@ -134,10 +345,14 @@ func (c *SSHConnection) terminateIfLifetimeExpired(expiredConnectionTimeout time
 
 func (c *SSHConnection) testSSHClient() bool {
 	sshClient := c.getSSHClient()
+	if sshClient == nil {
+		return false
+	}
 
 	_, _, err := sshClient.SendRequest("keepalive@golang.org", true, nil)
 	if err != nil {
 		log.Infof("SSH keepalive request to %s failed: %v", c.device, err)
+		internalmetrics.KeepaliveFailures.WithLabelValues(c.device.Host).Inc()
 		c.Stop(fmt.Errorf("keepalive failed"))
 		return false
 	}
@@ -146,24 +361,43 @@ func (c *SSHConnection) testSSHClient() bool {
 }
 
 func (c *SSHConnection) connect() error {
+	auth := &AuthConfig{}
+	c.device.Auth(auth)
+	defer auth.close()
+
 	cfg := &ssh.ClientConfig{
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         timeoutInSeconds * time.Second,
+		User:            auth.User,
+		Auth:            auth.Auth,
+		HostKeyCallback: c.device.HostKeyCallback,
+		Timeout:         c.connectTimeout,
+	}
+
+	if cfg.HostKeyCallback == nil {
+		cfg.HostKeyCallback = ssh.InsecureIgnoreHostKey()
 	}
 
-	c.device.Auth(cfg)
+	host := c.device.address()
+	if c.parent != nil {
+		log.Infof("Establishing TCP connection with %s via jump host %s", host, c.parent.Host())
+	} else {
+		log.Infof("Establishing TCP connection with %s", host)
+	}
 
-	host := tcpAddressForHost(c.device.Host)
-	log.Infof("Establishing TCP connection with %s", host)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
 
-	tcpConn, err := net.DialTimeout("tcp", host, cfg.Timeout)
+	internalmetrics.ConnectionAttempts.WithLabelValues(c.device.Host).Inc()
+
+	tcpConn, err := c.dial(ctx, "tcp", host)
 	if err != nil {
+		internalmetrics.ConnectionFailures.WithLabelValues(c.device.Host, dialFailureReason(err)).Inc()
 		return fmt.Errorf("could not open tcp connection: %w", err)
 	}
 
 	sshConn, chans, reqs, err := ssh.NewClientConn(tcpConn, host, cfg)
 	if err != nil {
 		tcpConn.Close()
+		internalmetrics.ConnectionFailures.WithLabelValues(c.device.Host, handshakeFailureReason(err)).Inc()
 		return fmt.Errorf("could not connect to device: %w", err)
 	}
 
@@ -173,15 +407,48 @@ func (c *SSHConnection) connect() error {
 	c.tcpConn = tcpConn
 	c.sshClient = ssh.NewClient(sshConn, chans, reqs)
 	c.isConnected = true
+	internalmetrics.ActiveConnections.Inc()
 
 	return nil
 }
 
+func dialFailureReason(err error) string {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return internalmetrics.ReasonTimeout
+	}
+
+	return internalmetrics.ReasonDial
+}
+
+func handshakeFailureReason(err error) string {
+	var mismatchErr *HostKeyMismatchError
+	var keyErr *knownhosts.KeyError
+	if errors.As(err, &mismatchErr) || errors.As(err, &keyErr) {
+		return internalmetrics.ReasonHostKey
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return internalmetrics.ReasonTimeout
+	}
+
+	if strings.Contains(err.Error(), "unable to authenticate") {
+		return internalmetrics.ReasonAuth
+	}
+
+	return internalmetrics.ReasonHandshake
+}
+
 func (c *SSHConnection) setLastUsed(t time.Time) {
 	c.lastUsedMu.Lock()
-	defer c.lastUsedMu.Unlock()
-
 	c.lastUsed = t
+	c.lastUsedMu.Unlock()
+
+	// a jump host is in use as long as connections are tunneled through it
+	if c.parent != nil {
+		c.parent.setLastUsed(t)
+	}
 }
 
 func (c *SSHConnection) GetLastUsed() time.Time {
@@ -198,6 +465,13 @@ func (c *SSHConnection) getSSHClient() *ssh.Client {
 	return c.sshClient
 }
 
+func (c *SSHConnection) getTCPConn() net.Conn {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.tcpConn
+}
+
 func (c *SSHConnection) IsConnected() bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -3,6 +3,7 @@
 package connector
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"strings"
@@ -10,6 +11,8 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+
+	"github.com/czerwonk/junos_exporter/pkg/internalmetrics"
 )
 
 const timeoutInSeconds = 5
@@ -18,13 +21,28 @@ const defaultPort = "22"
 // Option defines options for the manager which are applied on creation
 type Option func(*SSHConnectionManager)
 
-// WithReconnectInterval sets the reconnect interval (default 10 seconds)
+// WithReconnectInterval sets the initial interval to wait before reconnecting to a device marked down (default 30 seconds).
+// The interval doubles with every failed reconnect up to the max reconnect interval.
 func WithReconnectInterval(d time.Duration) Option {
 	return func(m *SSHConnectionManager) {
 		m.reconnectInterval = d
 	}
 }
 
+// WithMaxReconnectInterval sets the upper bound of the interval between reconnects to a device marked down (default 10 minutes)
+func WithMaxReconnectInterval(d time.Duration) Option {
+	return func(m *SSHConnectionManager) {
+		m.maxReconnectInterval = d
+	}
+}
+
+// WithFailureThreshold sets the number of consecutive failed connection attempts after a device is marked down (default 3)
+func WithFailureThreshold(n int) Option {
+	return func(m *SSHConnectionManager) {
+		m.failureThreshold = n
+	}
+}
+
 // WithKeepAliveInterval sets the keep alive interval (default 10 seconds)
 func WithKeepAliveInterval(d time.Duration) Option {
 	return func(m *SSHConnectionManager) {
@@ -39,6 +57,13 @@ func WithKeepAliveTimeout(d time.Duration) Option {
 	}
 }
 
+// WithConnectTimeout sets the timeout for establishing an ssh connection (default 5 seconds)
+func WithConnectTimeout(d time.Duration) Option {
+	return func(m *SSHConnectionManager) {
+		m.connectTimeout = d
+	}
+}
+
 // WithExpiredConnectionTime sets the timeout after an unused ssh connection will not be keepalived
 func WithExpiredConnectionTimeout(d time.Duration) Option {
 	return func(m *SSHConnectionManager) {
@@ -51,18 +76,30 @@ type SSHConnectionManager struct {
 	connections              map[string]*SSHConnection
 	connectionsMu            sync.RWMutex
 	reconnectInterval        time.Duration
+	maxReconnectInterval     time.Duration
+	failureThreshold         int
+	connectTimeout           time.Duration
 	keepAliveInterval        time.Duration
 	keepAliveTimeout         time.Duration
 	expiredConnectionTimeout time.Duration
+	hostKeyMismatches        map[string]uint64
+	hostKeyMismatchesMu      sync.RWMutex
+	breakers                 map[string]*circuitBreaker
+	breakersMu               sync.Mutex
 }
 
 // NewConnectionManager creates a new connection manager
 func NewConnectionManager(opts ...Option) *SSHConnectionManager {
 	m := &SSHConnectionManager{
-		connections:       make(map[string]*SSHConnection),
-		reconnectInterval: 30 * time.Second,
-		keepAliveInterval: 10 * time.Second,
-		keepAliveTimeout:  15 * time.Second,
+		connections:          make(map[string]*SSHConnection),
+		hostKeyMismatches:    make(map[string]uint64),
+		breakers:             make(map[string]*circuitBreaker),
+		reconnectInterval:    30 * time.Second,
+		maxReconnectInterval: 10 * time.Minute,
+		failureThreshold:     3,
+		connectTimeout:       timeoutInSeconds * time.Second,
+		keepAliveInterval:    10 * time.Second,
+		keepAliveTimeout:     15 * time.Second,
 	}
 
 	for _, opt := range opts {
@@ -74,20 +111,20 @@ func NewConnectionManager(opts ...Option) *SSHConnectionManager {
 
 // GetSSHConnection gets a cached SSHConnection or creates a fresh one, if necessary
 func (m *SSHConnectionManager) GetSSHConnection(device *Device) (*SSHConnection, error) {
-	connection := m.getExistingConnection(device)
+	connection := m.getExistingConnection(device.Host)
 	if connection != nil {
 		log.Infof("Re-using existing connection with %s", device.Host)
 		return connection, nil
 	}
 
-	return m.connect(device)
+	return m.connect(device.Host, device, device.JumpHosts)
 }
 
-func (m *SSHConnectionManager) getExistingConnection(device *Device) *SSHConnection {
+func (m *SSHConnectionManager) getExistingConnection(key string) *SSHConnection {
 	m.connectionsMu.RLock()
 	defer m.connectionsMu.RUnlock()
 
-	if connection, found := m.connections[device.Host]; found {
+	if connection, found := m.connections[key]; found {
 		if connection.IsConnected() {
 			return connection
 		}
@@ -96,26 +133,132 @@ func (m *SSHConnectionManager) getExistingConnection(device *Device) *SSHConnect
 	return nil
 }
 
-func (m *SSHConnectionManager) connect(device *Device) (*SSHConnection, error) {
-	log.Infof("Creating SSH connection with %s", device.Host)
-	c := NewSSHConnection(device, m.keepAliveInterval, m.keepAliveTimeout)
-	err := c.Start(m.expiredConnectionTimeout)
+// getJumpConnection gets the connection to the last jump host of the chain, which is shared by all devices behind it
+func (m *SSHConnectionManager) getJumpConnection(chain []*Device) (*SSHConnection, error) {
+	hosts := make([]string, len(chain))
+	for i, d := range chain {
+		hosts[i] = d.Host
+	}
+	key := "jump:" + strings.Join(hosts, ">")
+
+	connection := m.getExistingConnection(key)
+	if connection != nil {
+		return connection, nil
+	}
+
+	return m.connect(key, chain[len(chain)-1], chain[:len(chain)-1])
+}
+
+func (m *SSHConnectionManager) connect(key string, device *Device, jumpHosts []*Device) (*SSHConnection, error) {
+	b := m.breakerFor(key, device)
+	err := b.allow(time.Now())
 	if err != nil {
-		return nil, fmt.Errorf("unable to get new SSH connection: %w", err)
+		return nil, err
+	}
+
+	c, err := m.start(device, jumpHosts)
+	if err != nil {
+		b.failure(time.Now())
+		if state, nextRetry := b.status(); state == BreakerOpen {
+			log.Warnf("Marking %s down, next connection attempt at %s", device.Host, nextRetry.Format(time.RFC3339))
+		}
+
+		return nil, err
 	}
 
+	b.success()
+
 	m.connectionsMu.Lock()
 	defer m.connectionsMu.Unlock()
 
-	if existingCon, exists := m.connections[device.Host]; exists && existingCon.IsConnected() {
+	if existingCon, exists := m.connections[key]; exists && existingCon.IsConnected() {
 		c.Stop(fmt.Errorf("connection conflict"))
 		return existingCon, nil
 	}
 
-	m.connections[device.Host] = c
+	m.connections[key] = c
+	return c, nil
+}
+
+func (m *SSHConnectionManager) start(device *Device, jumpHosts []*Device) (*SSHConnection, error) {
+	log.Infof("Creating SSH connection with %s", device.Host)
+	c := NewSSHConnection(device, valueOrDefault(device.KeepAliveInterval, m.keepAliveInterval), valueOrDefault(device.KeepAliveTimeout, m.keepAliveTimeout))
+	c.connectTimeout = valueOrDefault(device.ConnectTimeout, m.connectTimeout)
+
+	if len(jumpHosts) > 0 {
+		jump, err := m.getJumpConnection(jumpHosts)
+		if err != nil {
+			internalmetrics.ConnectionFailures.WithLabelValues(device.Host, internalmetrics.ReasonJumpHost).Inc()
+			return nil, fmt.Errorf("unable to connect to jump host: %w", err)
+		}
+
+		c.tunnelThrough(jump)
+	}
+
+	err := c.Start(valueOrDefault(device.ExpireTimeout, m.expiredConnectionTimeout))
+	if err != nil {
+		var mismatchErr *HostKeyMismatchError
+		if errors.As(err, &mismatchErr) {
+			m.recordHostKeyMismatch(device)
+		}
+
+		return nil, fmt.Errorf("unable to get new SSH connection: %w", err)
+	}
+
 	return c, nil
 }
 
+func (m *SSHConnectionManager) breakerFor(key string, device *Device) *circuitBreaker {
+	m.breakersMu.Lock()
+	defer m.breakersMu.Unlock()
+
+	b, found := m.breakers[key]
+	if !found {
+		b = newCircuitBreaker(device.Host, m.failureThreshold, m.reconnectInterval, m.maxReconnectInterval)
+		m.breakers[key] = b
+	}
+
+	return b
+}
+
+// BreakerStatus returns the state of the circuit breaker of the device and the time the next connection attempt is allowed
+// (zero if the device is not marked down)
+func (m *SSHConnectionManager) BreakerStatus(device *Device) (BreakerState, time.Time) {
+	m.breakersMu.Lock()
+	b, found := m.breakers[device.Host]
+	m.breakersMu.Unlock()
+
+	if !found {
+		return BreakerClosed, time.Time{}
+	}
+
+	return b.status()
+}
+
+func (m *SSHConnectionManager) recordHostKeyMismatch(device *Device) {
+	m.hostKeyMismatchesMu.Lock()
+	defer m.hostKeyMismatchesMu.Unlock()
+
+	m.hostKeyMismatches[device.Host]++
+}
+
+// HostKeyMismatches returns the number of connections to the device blocked because of a host key mismatch
+func (m *SSHConnectionManager) HostKeyMismatches(device *Device) uint64 {
+	m.hostKeyMismatchesMu.RLock()
+	defer m.hostKeyMismatchesMu.RUnlock()
+
+	return m.hostKeyMismatches[device.Host]
+}
+
+// valueOrDefault returns d or def if d is not set
+func valueOrDefault(d, def time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+
+	return def
+}
+
 func tcpAddressForHost(host string) string {
 	colonCount := strings.Count(host, ":")
 
@@ -4,6 +4,7 @@ package connector
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -59,3 +60,81 @@ func TestTCPAddressForHost(t *testing.T) {
 		})
 	}
 }
+
+func TestDeviceAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		device   *Device
+		expected string
+	}{
+		{
+			name:     "port from host",
+			device:   &Device{Host: "router1:2222"},
+			expected: "router1:2222",
+		},
+		{
+			name:     "port set",
+			device:   &Device{Host: "router1", Port: 830},
+			expected: "router1:830",
+		},
+		{
+			name:     "port set overrides port in host",
+			device:   &Device{Host: "[2001:678:1e0:f00::1]:22", Port: 2222},
+			expected: "[2001:678:1e0:f00::1]:2222",
+		},
+		{
+			name:     "port set for IPv6 without brackets",
+			device:   &Device{Host: "2001:678:1e0:f00::1", Port: 2222},
+			expected: "[2001:678:1e0:f00::1]:2222",
+		},
+	}
+
+	t.Parallel()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.device.address())
+		})
+	}
+}
+
+func TestConnectionThroughJumpHost(t *testing.T) {
+	bastion := newTestSSHServer(t, func(cmd string) string { return "bastion" })
+	router := newTestSSHServer(t, func(cmd string) string { return "router: " + cmd })
+
+	m := NewConnectionManager(
+		WithKeepAliveInterval(50*time.Millisecond),
+		WithExpiredConnectionTimeout(time.Minute),
+	)
+	defer m.CloseAll()
+
+	d := &Device{
+		Host: router.Addr(),
+		Auth: AuthByPassword("exporter", "secret"),
+		JumpHosts: []*Device{
+			{
+				Host: bastion.Addr(),
+				Auth: AuthByPassword("jump", "secret"),
+			},
+		},
+	}
+
+	conn, err := m.GetSSHConnection(d)
+	assert.NoError(t, err)
+
+	b, err := conn.RunCommand("show version")
+	assert.NoError(t, err)
+	assert.Equal(t, "router: show version", string(b))
+
+	bastion.CloseConnections()
+	assert.Eventually(t, func() bool {
+		return !conn.IsConnected()
+	}, 5*time.Second, 10*time.Millisecond, "connection should be stopped when jump host connection drops")
+
+	conn, err = m.GetSSHConnection(d)
+	assert.NoError(t, err, "reconnect through jump host")
+
+	b, err = conn.RunCommand("show version")
+	assert.NoError(t, err)
+	assert.Equal(t, "router: show version", string(b))
+}
@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: MIT
+
+package connector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCommandContextDeadline(t *testing.T) {
+	release := make(chan struct{})
+	router := newTestSSHServer(t, func(cmd string) string {
+		if cmd == "show interfaces extensive" {
+			<-release
+		}
+
+		return "router: " + cmd
+	})
+	t.Cleanup(func() { close(release) })
+
+	m := NewConnectionManager()
+	defer m.CloseAll()
+
+	conn, err := m.GetSSHConnection(&Device{
+		Host: router.Addr(),
+		Auth: AuthByPassword("exporter", "secret"),
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = conn.RunCommandContext(ctx, "show interfaces extensive")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "expected deadline exceeded, got: %v", err)
+	assert.Less(t, time.Since(start), 5*time.Second, "command should be aborted at the deadline")
+
+	assert.True(t, conn.IsConnected(), "connection should be kept after aborting a session")
+
+	b, err := conn.RunCommand("show version")
+	assert.NoError(t, err)
+	assert.Equal(t, "router: show version", string(b))
+
+	_, err = conn.RunCommandContext(ctx, "show version")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "commands should not be started after the deadline, got: %v", err)
+}
+
+func TestRunNetconfRPCContextDeadline(t *testing.T) {
+	release := make(chan struct{})
+	router := newTestSSHServer(t, func(rpc string) string {
+		if rpc == "<get-interface-information/>" {
+			<-release
+		}
+
+		return "<software-information/>"
+	})
+	t.Cleanup(func() { close(release) })
+
+	m := NewConnectionManager()
+	defer m.CloseAll()
+
+	conn, err := m.GetSSHConnection(&Device{
+		Host: router.Addr(),
+		Auth: AuthByPassword("exporter", "secret"),
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 200*time.Millisecond)
+	defer cancel()
+
+	aborted := make(chan error)
+	go func() {
+		_, err := conn.RunNetconfRPCContext(ctx, "<get-interface-information/>")
+		aborted <- err
+	}()
+
+	// runs in a session of its own while the slow RPC is running
+	time.Sleep(50 * time.Millisecond)
+	b, err := conn.RunNetconfRPCContext(ctx, "<get-software-information/>")
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "<software-information/>")
+
+	err = <-aborted
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "expected deadline exceeded, got: %v", err)
+	assert.True(t, conn.IsConnected(), "connection should be kept after aborting a NETCONF session")
+
+	b, err = conn.RunNetconfRPC("<get-software-information/>")
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "<software-information/>")
+}
@@ -4,6 +4,9 @@ package connector
 
 import (
 	"io"
+	"net"
+	"strconv"
+	"time"
 
 	"golang.org/x/crypto/ssh"
 )
@@ -12,14 +15,32 @@ import (
 type Device struct {
 	Host string
 	Auth AuthMethod
+
+	// HostKeyCallback is used to verify the host key of the device. If not set, the host key is not verified.
+	HostKeyCallback ssh.HostKeyCallback
+
+	// Dialer is used to open the connection to the device (e.g. through a proxy). If not set, the device is dialed directly.
+	Dialer Dialer
+
+	// JumpHosts are the hosts to connect through to reach the device (ProxyJump semantics, first host is connected to first)
+	JumpHosts []*Device
+
+	// Port is the SSH port of the device (overrides a port in Host). If not set, the port in Host or 22 is used.
+	Port int
+
+	// ConnectTimeout, KeepAliveInterval, KeepAliveTimeout and ExpireTimeout override the settings of the connection manager if set
+	ConnectTimeout    time.Duration
+	KeepAliveInterval time.Duration
+	KeepAliveTimeout  time.Duration
+	ExpireTimeout     time.Duration
 }
 
 // AuthMethod is the method to use to authenticate agaist the device
-type AuthMethod func(*ssh.ClientConfig)
+type AuthMethod func(*AuthConfig)
 
 // AuthByPassword uses password authentication
 func AuthByPassword(username, password string) AuthMethod {
-	return func(cfg *ssh.ClientConfig) {
+	return func(cfg *AuthConfig) {
 		cfg.User = username
 		cfg.Auth = append(cfg.Auth, ssh.Password(password))
 	}
@@ -32,10 +53,24 @@ func AuthByKey(username string, key io.Reader, keyPassphrase string) (AuthMethod
 		return nil, err
 	}
 
-	return func(cfg *ssh.ClientConfig) {
-		cfg.User = username
-		cfg.Auth = append(cfg.Auth, pk)
-	}, nil
+	return authBySigners(username, func() ([]ssh.Signer, error) {
+		return []ssh.Signer{pk}, nil
+	}), nil
+}
+
+// address returns the TCP address to connect to
+func (d *Device) address() string {
+	addr := tcpAddressForHost(d.Host)
+	if d.Port == 0 {
+		return addr
+	}
+
+	h, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	return net.JoinHostPort(h, strconv.Itoa(d.Port))
 }
 
 func (d *Device) String() string {
@@ -9,7 +9,7 @@ import (
 	"golang.org/x/crypto/ssh"
 )
 
-func loadPrivateKey(r io.Reader, keyPassphrase string) (ssh.AuthMethod, error) {
+func loadPrivateKey(r io.Reader, keyPassphrase string) (ssh.Signer, error) {
 	b, err := io.ReadAll(r)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not read from reader")
@@ -26,5 +26,29 @@ func loadPrivateKey(r io.Reader, keyPassphrase string) (ssh.AuthMethod, error) {
 		return nil, errors.Wrap(err, "could not parse private key")
 	}
 
-	return ssh.PublicKeys(key), nil
+	return key, nil
+}
+
+func loadCertificate(r io.Reader, key ssh.Signer) (ssh.Signer, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read from reader")
+	}
+
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse certificate")
+	}
+
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, errors.New("public key is not a certificate")
+	}
+
+	signer, err := ssh.NewCertSigner(cert, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "certificate does not match private key")
+	}
+
+	return signer, nil
 }
@@ -0,0 +1,191 @@
+// SPDX-License-Identifier: MIT
+
+package connector
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+var (
+	knownHostsFiles   = make(map[string]*knownHostsFile)
+	knownHostsFilesMu sync.Mutex
+)
+
+// HostKeyMismatchError is returned when the host key presented by a device does not match the expected host key(s)
+type HostKeyMismatchError struct {
+	Host     string
+	KeyType  string
+	Actual   string
+	Expected []string
+}
+
+// Error implements the error interface
+func (e *HostKeyMismatchError) Error() string {
+	return fmt.Sprintf("host key mismatch for %s: got %s key %s, expected %s", e.Host, e.KeyType, e.Actual, strings.Join(e.Expected, ", "))
+}
+
+// VerifyHostKeyByFingerprints accepts only host keys matching one of the pinned fingerprints.
+// Fingerprints can be specified in SHA256 (SHA256:...) or legacy MD5 (aa:bb:...) format.
+func VerifyHostKeyByFingerprints(fingerprints ...string) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		sha256 := ssh.FingerprintSHA256(key)
+		md5 := ssh.FingerprintLegacyMD5(key)
+
+		for _, f := range fingerprints {
+			f = strings.TrimSpace(f)
+			if f == sha256 || strings.TrimPrefix(strings.ToLower(f), "md5:") == md5 {
+				return nil
+			}
+		}
+
+		return hostKeyMismatch(hostname, key, fingerprints)
+	}
+}
+
+// VerifyHostKeyByKnownHosts accepts only host keys listed in the OpenSSH known_hosts file.
+// If trustOnFirstUse is set keys of unknown hosts are accepted and appended to the file.
+func VerifyHostKeyByKnownHosts(file string, trustOnFirstUse bool) (ssh.HostKeyCallback, error) {
+	f, err := knownHostsFileForPath(file, trustOnFirstUse)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		return f.verify(hostname, remote, key, trustOnFirstUse)
+	}, nil
+}
+
+type knownHostsFile struct {
+	path     string
+	callback ssh.HostKeyCallback
+	mu       sync.RWMutex
+}
+
+func knownHostsFileForPath(path string, create bool) (*knownHostsFile, error) {
+	knownHostsFilesMu.Lock()
+	defer knownHostsFilesMu.Unlock()
+
+	if f, found := knownHostsFiles[path]; found {
+		return f, nil
+	}
+
+	if create {
+		err := createFileIfNotExists(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not create known hosts file %s: %w", path, err)
+		}
+	}
+
+	f := &knownHostsFile{path: path}
+	err := f.load()
+	if err != nil {
+		return nil, err
+	}
+
+	knownHostsFiles[path] = f
+	return f, nil
+}
+
+func createFileIfNotExists(path string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+func (f *knownHostsFile) load() error {
+	cb, err := knownhosts.New(f.path)
+	if err != nil {
+		return fmt.Errorf("could not load known hosts file %s: %w", f.path, err)
+	}
+
+	f.callback = cb
+	return nil
+}
+
+func (f *knownHostsFile) verify(hostname string, remote net.Addr, key ssh.PublicKey, trustOnFirstUse bool) error {
+	f.mu.RLock()
+	err := f.callback(hostname, remote, key)
+	f.mu.RUnlock()
+
+	var keyErr *knownhosts.KeyError
+	if err == nil || !errors.As(err, &keyErr) {
+		return err
+	}
+
+	if len(keyErr.Want) > 0 {
+		expected := make([]string, len(keyErr.Want))
+		for i, k := range keyErr.Want {
+			expected[i] = fmt.Sprintf("%s %s (%s:%d)", k.Key.Type(), ssh.FingerprintSHA256(k.Key), k.Filename, k.Line)
+		}
+
+		return hostKeyMismatch(hostname, key, expected)
+	}
+
+	if !trustOnFirstUse {
+		return fmt.Errorf("host key of %s is unknown (%s %s)", hostname, key.Type(), ssh.FingerprintSHA256(key))
+	}
+
+	return f.learn(hostname, remote, key)
+}
+
+func (f *knownHostsFile) learn(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// the key might have been learned in the meantime by a concurrent connection
+	err := f.callback(hostname, remote, key)
+	if err == nil {
+		return nil
+	}
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("could not open known hosts file %s: %w", f.path, err)
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key) + "\n")
+	if err != nil {
+		return fmt.Errorf("could not write to known hosts file %s: %w", f.path, err)
+	}
+
+	log.Infof("Learned host key of %s (trust on first use): %s %s", hostname, key.Type(), ssh.FingerprintSHA256(key))
+
+	return f.load()
+}
+
+func hostKeyMismatch(hostname string, key ssh.PublicKey, expected []string) error {
+	err := &HostKeyMismatchError{
+		Host:     hostname,
+		KeyType:  key.Type(),
+		Actual:   ssh.FingerprintSHA256(key),
+		Expected: expected,
+	}
+
+	log.WithFields(log.Fields{
+		"host":     hostname,
+		"key_type": err.KeyType,
+		"actual":   err.Actual,
+		"expected": strings.Join(expected, ", "),
+	}).Error("SSH host key mismatch, connection blocked. If the device (or its routing engine) was replaced, the stored host key has to be updated.")
+
+	return err
+}
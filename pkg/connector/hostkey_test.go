@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: MIT
+
+package connector
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestVerifyHostKeyByFingerprints(t *testing.T) {
+	key := generateHostKey(t)
+	otherKey := generateHostKey(t)
+
+	cb := VerifyHostKeyByFingerprints(ssh.FingerprintSHA256(key), ssh.FingerprintLegacyMD5(otherKey))
+	addr := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 22}
+
+	assert.NoError(t, cb("router1:22", addr, key), "SHA256 fingerprint")
+	assert.NoError(t, cb("router1:22", addr, otherKey), "MD5 fingerprint")
+
+	var mismatchErr *HostKeyMismatchError
+	err := cb("router1:22", addr, generateHostKey(t))
+	assert.True(t, errors.As(err, &mismatchErr), "unknown key should be a mismatch")
+}
+
+func TestVerifyHostKeyByKnownHostsTrustOnFirstUse(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "known_hosts")
+	key := generateHostKey(t)
+	addr := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 22}
+
+	cb, err := VerifyHostKeyByKnownHosts(file, true)
+	assert.NoError(t, err)
+
+	assert.NoError(t, cb("router1:22", addr, key), "first use")
+	assert.NoError(t, cb("router1:22", addr, key), "learned key")
+
+	var mismatchErr *HostKeyMismatchError
+	err = cb("router1:22", addr, generateHostKey(t))
+	assert.True(t, errors.As(err, &mismatchErr), "changed key should be a mismatch")
+
+	b, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "router1 ssh-ed25519 ")
+}
+
+func TestVerifyHostKeyByKnownHostsRejectsUnknownHosts(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "known_hosts")
+	err := os.WriteFile(file, []byte{}, 0600)
+	assert.NoError(t, err)
+
+	cb, err := VerifyHostKeyByKnownHosts(file, false)
+	assert.NoError(t, err)
+
+	err = cb("router2:22", &net.TCPAddr{IP: net.ParseIP("192.0.2.2"), Port: 22}, generateHostKey(t))
+	assert.ErrorContains(t, err, "is unknown")
+}
+
+func generateHostKey(t *testing.T) ssh.PublicKey {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
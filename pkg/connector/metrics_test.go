@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: MIT
+
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/czerwonk/junos_exporter/pkg/internalmetrics"
+)
+
+func TestConnectionMetrics(t *testing.T) {
+	router := newTestSSHServer(t, func(cmd string) string { return "" })
+	target := router.Addr()
+
+	attempts := testutil.ToFloat64(internalmetrics.ConnectionAttempts.WithLabelValues(target))
+	dialFailures := testutil.ToFloat64(internalmetrics.ConnectionFailures.WithLabelValues(target, internalmetrics.ReasonDial))
+	active := testutil.ToFloat64(internalmetrics.ActiveConnections)
+
+	m := NewConnectionManager()
+	defer m.CloseAll()
+
+	_, err := m.GetSSHConnection(&Device{
+		Host: target,
+		Auth: AuthByPassword("exporter", "secret"),
+		Dialer: dialerFunc(func(ctx context.Context, network, address string) (net.Conn, error) {
+			return nil, errors.New("connection refused")
+		}),
+	})
+	assert.Error(t, err)
+	assert.Equal(t, dialFailures+1, testutil.ToFloat64(internalmetrics.ConnectionFailures.WithLabelValues(target, internalmetrics.ReasonDial)))
+
+	conn, err := m.GetSSHConnection(&Device{
+		Host: target,
+		Auth: AuthByPassword("exporter", "secret"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, attempts+2, testutil.ToFloat64(internalmetrics.ConnectionAttempts.WithLabelValues(target)))
+	assert.Equal(t, active+1, testutil.ToFloat64(internalmetrics.ActiveConnections))
+
+	conn.Stop(fmt.Errorf("test finished"))
+	assert.Equal(t, active, testutil.ToFloat64(internalmetrics.ActiveConnections))
+}
+
+func TestHandshakeFailureReason(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "host key mismatch",
+			err:      fmt.Errorf("ssh: handshake failed: %w", &HostKeyMismatchError{Host: "router1"}),
+			expected: internalmetrics.ReasonHostKey,
+		},
+		{
+			name:     "authentication",
+			err:      errors.New("ssh: handshake failed: ssh: unable to authenticate, attempted methods [none password], no supported methods remain"),
+			expected: internalmetrics.ReasonAuth,
+		},
+		{
+			name:     "other",
+			err:      errors.New("ssh: handshake failed: EOF"),
+			expected: internalmetrics.ReasonHandshake,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, handshakeFailureReason(test.err))
+		})
+	}
+}
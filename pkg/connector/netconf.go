@@ -0,0 +1,292 @@
+// SPDX-License-Identifier: MIT
+
+package connector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	netconfSubsystem = "netconf"
+	netconfDelimiter = "]]>]]>"
+	netconfNamespace = "urn:ietf:params:xml:ns:netconf:base:1.0"
+	netconfBase10    = "urn:ietf:params:netconf:base:1.0"
+	netconfBase11    = "urn:ietf:params:netconf:base:1.1"
+	netconfChunkSize = 65536
+)
+
+// errNetconfSessionAborted is returned to RPCs waiting for a session which was aborted by the RPC running before them
+var errNetconfSessionAborted = errors.New("NETCONF session was aborted")
+
+// netconfSession is a NETCONF session (RFC 6242) running in the netconf subsystem of an SSH connection
+type netconfSession struct {
+	client    *ssh.Client
+	session   *ssh.Session
+	w         io.WriteCloser
+	r         *bufio.Reader
+	chunked   bool
+	messageID uint64
+
+	// lock is held while an RPC is running. It is a channel, so waiting for it can be cancelled.
+	lock    chan struct{}
+	aborted atomic.Bool
+}
+
+type netconfHello struct {
+	XMLName      xml.Name `xml:"hello"`
+	Capabilities []string `xml:"capabilities>capability"`
+	SessionID    int      `xml:"session-id"`
+}
+
+type netconfReplyHeader struct {
+	XMLName   xml.Name
+	MessageID string `xml:"message-id,attr"`
+}
+
+func newNetconfSession(client *ssh.Client) (*netconfSession, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open session")
+	}
+
+	w, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, errors.Wrap(err, "could not get stdin of session")
+	}
+
+	r, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, errors.Wrap(err, "could not get stdout of session")
+	}
+
+	err = session.RequestSubsystem(netconfSubsystem)
+	if err != nil {
+		session.Close()
+		return nil, errors.Wrap(err, "could not start netconf subsystem")
+	}
+
+	s := &netconfSession{
+		client:  client,
+		session: session,
+		w:       w,
+		r:       bufio.NewReader(r),
+		lock:    make(chan struct{}, 1),
+	}
+
+	err = s.exchangeHello()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// exchangeHello sends our capabilities and determines the framing to use by the capabilities of the server
+func (s *netconfSession) exchangeHello() error {
+	hello := `<hello xmlns="` + netconfNamespace + `"><capabilities>` +
+		`<capability>` + netconfBase10 + `</capability>` +
+		`<capability>` + netconfBase11 + `</capability>` +
+		`</capabilities></hello>`
+
+	// hello messages are always framed using the end-of-message delimiter
+	err := writeNetconfMessage(s.w, []byte(hello), false)
+	if err != nil {
+		return errors.Wrap(err, "could not send netconf hello")
+	}
+
+	b, err := readNetconfMessage(s.r, false)
+	if err != nil {
+		return errors.Wrap(err, "could not read netconf hello")
+	}
+
+	var h netconfHello
+	err = xml.Unmarshal(b, &h)
+	if err != nil {
+		return errors.Wrap(err, "could not parse netconf hello")
+	}
+
+	for _, c := range h.Capabilities {
+		if strings.TrimSpace(c) == netconfBase11 {
+			s.chunked = true
+		}
+	}
+
+	return nil
+}
+
+// exec sends an RPC to the device and returns the raw rpc-reply.
+// If the context is done while the RPC is running, the session is aborted, as a late reply would desynchronize it.
+func (s *netconfSession) exec(ctx context.Context, rpc string) ([]byte, error) {
+	select {
+	case s.lock <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-s.lock }()
+
+	if s.aborted.Load() {
+		return nil, errNetconfSessionAborted
+	}
+
+	stop := context.AfterFunc(ctx, s.abort)
+	defer stop()
+
+	s.messageID++
+	id := strconv.FormatUint(s.messageID, 10)
+
+	msg := `<rpc message-id="` + id + `" xmlns="` + netconfNamespace + `">` + rpc + `</rpc>`
+	err := writeNetconfMessage(s.w, []byte(msg), s.chunked)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not send rpc")
+	}
+
+	b, err := readNetconfMessage(s.r, s.chunked)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read rpc-reply")
+	}
+
+	var h netconfReplyHeader
+	err = xml.NewDecoder(bytes.NewReader(b)).Decode(&h)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse rpc-reply")
+	}
+
+	if h.XMLName.Local != "rpc-reply" {
+		return nil, fmt.Errorf("unexpected netconf message %q", h.XMLName.Local)
+	}
+
+	if h.MessageID != id {
+		return nil, fmt.Errorf("unexpected message-id in rpc-reply (expected: %s, got: %s)", id, h.MessageID)
+	}
+
+	return b, nil
+}
+
+func (s *netconfSession) close() {
+	s.lock <- struct{}{}
+	defer func() { <-s.lock }()
+
+	if s.aborted.Load() {
+		return
+	}
+
+	s.messageID++
+	msg := `<rpc message-id="` + strconv.FormatUint(s.messageID, 10) + `" xmlns="` + netconfNamespace + `"><close-session/></rpc>`
+	_ = writeNetconfMessage(s.w, []byte(msg), s.chunked)
+
+	s.session.Close()
+}
+
+// abort closes the session without sending close-session, pending reads and writes are interrupted.
+// RPCs waiting for the session fail with errNetconfSessionAborted.
+func (s *netconfSession) abort() {
+	s.aborted.Store(true)
+	_ = s.session.Signal(ssh.SIGKILL)
+	s.session.Close()
+}
+
+func writeNetconfMessage(w io.Writer, msg []byte, chunked bool) error {
+	if !chunked {
+		_, err := w.Write(append(msg, []byte(netconfDelimiter)...))
+		return err
+	}
+
+	b := &bytes.Buffer{}
+	for len(msg) > 0 {
+		n := len(msg)
+		if n > netconfChunkSize {
+			n = netconfChunkSize
+		}
+
+		fmt.Fprintf(b, "\n#%d\n", n)
+		b.Write(msg[:n])
+		msg = msg[n:]
+	}
+	b.WriteString("\n##\n")
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func readNetconfMessage(r *bufio.Reader, chunked bool) ([]byte, error) {
+	if chunked {
+		return readNetconfChunkedMessage(r)
+	}
+
+	b := &bytes.Buffer{}
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		b.WriteByte(c)
+		if c == '>' && bytes.HasSuffix(b.Bytes(), []byte(netconfDelimiter)) {
+			return bytes.TrimSpace(b.Bytes()[:b.Len()-len(netconfDelimiter)]), nil
+		}
+	}
+}
+
+func readNetconfChunkedMessage(r *bufio.Reader) ([]byte, error) {
+	b := &bytes.Buffer{}
+	for {
+		err := readNetconfChunkHeaderStart(r)
+		if err != nil {
+			return nil, err
+		}
+
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		if line == "#" {
+			return b.Bytes(), nil
+		}
+
+		n, err := strconv.ParseUint(line, 10, 32)
+		if err != nil || n == 0 {
+			return nil, fmt.Errorf("invalid chunk size %q", line)
+		}
+
+		_, err = io.CopyN(b, r, int64(n))
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// readNetconfChunkHeaderStart consumes the LF and HASH starting a chunk header. Additional whitespace sent by some
+// implementations between messages is skipped.
+func readNetconfChunkHeaderStart(r *bufio.Reader) error {
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+
+		switch c {
+		case '#':
+			return nil
+		case '\n', '\r', ' ', '\t':
+			continue
+		default:
+			return fmt.Errorf("invalid chunk framing: unexpected character %q", c)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: MIT
+
+package connector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetconfFraming(t *testing.T) {
+	tests := []struct {
+		name    string
+		chunked bool
+	}{
+		{
+			name:    "end-of-message delimiter (base:1.0)",
+			chunked: false,
+		},
+		{
+			name:    "chunked (base:1.1)",
+			chunked: true,
+		},
+	}
+
+	t.Parallel()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			msg := []byte(`<rpc-reply message-id="1"><ok/></rpc-reply>`)
+			b := &bytes.Buffer{}
+
+			err := writeNetconfMessage(b, msg, test.chunked)
+			assert.NoError(t, err)
+
+			err = writeNetconfMessage(b, []byte(strings.Repeat("x", netconfChunkSize+1)), test.chunked)
+			assert.NoError(t, err)
+
+			r := bufio.NewReader(b)
+			m1, err := readNetconfMessage(r, test.chunked)
+			assert.NoError(t, err)
+			assert.Equal(t, msg, m1)
+
+			m2, err := readNetconfMessage(r, test.chunked)
+			assert.NoError(t, err)
+			assert.Equal(t, netconfChunkSize+1, len(m2))
+		})
+	}
+}
+
+func TestNetconfChunkedMessageWithMultipleChunks(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("\n#4\n<rpc\n#18\n-reply message-id=\n#5\n\"1\"/>\n##\n"))
+
+	b, err := readNetconfMessage(r, true)
+	assert.NoError(t, err)
+	assert.Equal(t, `<rpc-reply message-id="1"/>`, string(b))
+}
+
+func TestNetconfInvalidChunkSize(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("\n#0\n\n##\n"))
+
+	_, err := readNetconfMessage(r, true)
+	assert.Error(t, err)
+}
+
+func TestNetconfSessionExec(t *testing.T) {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+
+	go func() {
+		r := bufio.NewReader(serverR)
+
+		_, _ = readNetconfMessage(r, false)
+		_ = writeNetconfMessage(serverW, []byte(`<hello xmlns="urn:ietf:params:xml:ns:netconf:base:1.0">
+  <capabilities>
+    <capability>urn:ietf:params:netconf:base:1.0</capability>
+    <capability>urn:ietf:params:netconf:base:1.1</capability>
+  </capabilities>
+  <session-id>4711</session-id>
+</hello>`), false)
+
+		_, _ = readNetconfMessage(r, true)
+		_ = writeNetconfMessage(serverW, []byte(`<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="1"><software-information/></rpc-reply>`), true)
+
+		_, _ = readNetconfMessage(r, true)
+		_ = writeNetconfMessage(serverW, []byte(`<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="42"><ok/></rpc-reply>`), true)
+	}()
+
+	s := &netconfSession{
+		w:    clientW,
+		r:    bufio.NewReader(clientR),
+		lock: make(chan struct{}, 1),
+	}
+
+	err := s.exchangeHello()
+	assert.NoError(t, err)
+	assert.True(t, s.chunked, "base:1.1 framing")
+
+	b, err := s.exec(t.Context(), `<get-software-information/>`)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "<software-information/>")
+
+	_, err = s.exec(t.Context(), `<get-software-information/>`)
+	assert.ErrorContains(t, err, "unexpected message-id")
+}
+
+func TestNetconfSessionExecWaitRespectsContext(t *testing.T) {
+	s := &netconfSession{
+		lock: make(chan struct{}, 1),
+	}
+	s.lock <- struct{}{}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := s.exec(ctx, `<get-software-information/>`)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
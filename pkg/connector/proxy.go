@@ -0,0 +1,198 @@
+// SPDX-License-Identifier: MIT
+
+package connector
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+func init() {
+	proxy.RegisterDialerType("http", newHTTPConnectDialer)
+}
+
+// Dialer opens the network connection to a device
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// ProxyDialer returns a dialer connecting through the proxy specified by the URL.
+// Supported schemes are socks5, socks5h (SOCKS5 with optional username/password auth) and http (HTTP CONNECT).
+func ProxyDialer(proxyURL string) (Dialer, error) {
+	d, err := proxyDialerFromURL(proxyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return asDialer(d), nil
+}
+
+func proxyDialerFromURL(proxyURL string) (proxy.Dialer, error) {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", proxyURL, err)
+	}
+
+	d, err := proxy.FromURL(u, proxy.Direct)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize proxy %s: %w", u.Redacted(), err)
+	}
+
+	return d, nil
+}
+
+// ProxyDialerFromEnvironment returns a dialer using the proxy configured in ALL_PROXY (hosts listed in NO_PROXY are dialed directly).
+// If no proxy is configured, a direct connection is used.
+func ProxyDialerFromEnvironment() (Dialer, error) {
+	allProxy := getEnvAny("ALL_PROXY", "all_proxy")
+	if allProxy == "" {
+		return proxy.Direct, nil
+	}
+
+	d, err := proxyDialerFromURL(allProxy)
+	if err != nil {
+		return nil, err
+	}
+
+	noProxy := getEnvAny("NO_PROXY", "no_proxy")
+	if noProxy == "" {
+		return asDialer(d), nil
+	}
+
+	perHost := proxy.NewPerHost(d, proxy.Direct)
+	perHost.AddFromString(noProxy)
+
+	return perHost, nil
+}
+
+func getEnvAny(names ...string) string {
+	for _, n := range names {
+		if val := os.Getenv(n); val != "" {
+			return val
+		}
+	}
+
+	return ""
+}
+
+func asDialer(d proxy.Dialer) Dialer {
+	if cd, ok := d.(Dialer); ok {
+		return cd
+	}
+
+	return &contextDialerAdapter{d}
+}
+
+type contextDialerAdapter struct {
+	proxy.Dialer
+}
+
+func (a *contextDialerAdapter) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return a.Dial(network, address)
+}
+
+// httpConnectDialer tunnels connections through a HTTP proxy using the CONNECT method
+type httpConnectDialer struct {
+	proxyAddress string
+	auth         string
+	forward      proxy.Dialer
+}
+
+func newHTTPConnectDialer(u *url.URL, forward proxy.Dialer) (proxy.Dialer, error) {
+	d := &httpConnectDialer{
+		proxyAddress: u.Host,
+		forward:      forward,
+	}
+
+	if u.Port() == "" {
+		d.proxyAddress = net.JoinHostPort(u.Hostname(), "8080")
+	}
+
+	if u.User != nil {
+		password, _ := u.User.Password()
+		d.auth = base64.StdEncoding.EncodeToString([]byte(u.User.Username() + ":" + password))
+	}
+
+	return d, nil
+}
+
+// Dial implements proxy.Dialer
+func (d *httpConnectDialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+// DialContext implements Dialer
+func (d *httpConnectDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	conn, err := dialForward(ctx, d.forward, network, d.proxyAddress)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to proxy %s: %w", d.proxyAddress, err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+
+	if d.auth != "" {
+		req.Header.Set("Proxy-Authorization", "Basic "+d.auth)
+	}
+
+	err = req.Write(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("could not send CONNECT request to proxy %s: %w", d.proxyAddress, err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("could not read CONNECT response from proxy %s: %w", d.proxyAddress, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s refused connection to %s: %s", d.proxyAddress, address, resp.Status)
+	}
+
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+
+	return conn, nil
+}
+
+func dialForward(ctx context.Context, forward proxy.Dialer, network, address string) (net.Conn, error) {
+	if cd, ok := forward.(proxy.ContextDialer); ok {
+		return cd.DialContext(ctx, network, address)
+	}
+
+	return forward.Dial(network, address)
+}
+
+// bufferedConn is a connection with data already read from the connection into a buffer
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
@@ -0,0 +1,316 @@
+// SPDX-License-Identifier: MIT
+
+package connector
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectionThroughProxy(t *testing.T) {
+	router := newTestSSHServer(t, func(cmd string) string { return "router: " + cmd })
+	socks := newTestSOCKS5Proxy(t, "noc", "secret")
+	httpProxy := newTestHTTPConnectProxy(t, "noc", "secret")
+
+	tests := []struct {
+		name     string
+		proxyURL string
+		used     *atomic.Int32
+	}{
+		{
+			name:     "SOCKS5",
+			proxyURL: "socks5://noc:secret@" + socks.addr,
+			used:     &socks.connections,
+		},
+		{
+			name:     "HTTP CONNECT",
+			proxyURL: "http://noc:secret@" + httpProxy.addr,
+			used:     &httpProxy.connections,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dialer, err := ProxyDialer(test.proxyURL)
+			assert.NoError(t, err)
+
+			m := NewConnectionManager()
+			defer m.CloseAll()
+
+			conn, err := m.GetSSHConnection(&Device{
+				Host:   router.Addr(),
+				Auth:   AuthByPassword("exporter", "secret"),
+				Dialer: dialer,
+			})
+			assert.NoError(t, err)
+
+			b, err := conn.RunCommand("show version")
+			assert.NoError(t, err)
+			assert.Equal(t, "router: show version", string(b))
+			assert.Equal(t, int32(1), test.used.Load(), "connections through proxy")
+		})
+	}
+}
+
+func TestProxyAuthenticationFailure(t *testing.T) {
+	router := newTestSSHServer(t, func(cmd string) string { return "" })
+	socks := newTestSOCKS5Proxy(t, "noc", "secret")
+	httpProxy := newTestHTTPConnectProxy(t, "noc", "secret")
+
+	for _, proxyURL := range []string{"socks5://noc:wrong@" + socks.addr, "http://noc:wrong@" + httpProxy.addr} {
+		dialer, err := ProxyDialer(proxyURL)
+		assert.NoError(t, err)
+
+		conn, err := dialer.DialContext(t.Context(), "tcp", router.Addr())
+		assert.Error(t, err, proxyURL)
+		assert.Nil(t, conn, proxyURL)
+	}
+}
+
+func TestProxyDialerFromEnvironment(t *testing.T) {
+	router := newTestSSHServer(t, func(cmd string) string { return "" })
+	socks := newTestSOCKS5Proxy(t, "", "")
+
+	t.Setenv("ALL_PROXY", "socks5://"+socks.addr)
+	t.Setenv("NO_PROXY", "")
+
+	dialer, err := ProxyDialerFromEnvironment()
+	assert.NoError(t, err)
+
+	conn, err := dialer.DialContext(t.Context(), "tcp", router.Addr())
+	assert.NoError(t, err)
+	conn.Close()
+	assert.Equal(t, int32(1), socks.connections.Load(), "connections through proxy")
+
+	t.Setenv("NO_PROXY", "127.0.0.1")
+
+	dialer, err = ProxyDialerFromEnvironment()
+	assert.NoError(t, err)
+
+	conn, err = dialer.DialContext(t.Context(), "tcp", router.Addr())
+	assert.NoError(t, err)
+	conn.Close()
+	assert.Equal(t, int32(1), socks.connections.Load(), "hosts in NO_PROXY should be dialed directly")
+}
+
+type testProxy struct {
+	addr        string
+	connections atomic.Int32
+}
+
+// newTestSOCKS5Proxy starts a minimal SOCKS5 proxy (RFC 1928) supporting the CONNECT command and username/password auth (RFC 1929)
+func newTestSOCKS5Proxy(t *testing.T, username, password string) *testProxy {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	p := &testProxy{addr: l.Addr().String()}
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				target, err := socks5Handshake(conn, username, password)
+				if err != nil {
+					return
+				}
+				defer target.Close()
+
+				p.connections.Add(1)
+				pipe(conn, target)
+			}()
+		}
+	}()
+
+	return p
+}
+
+func socks5Handshake(conn net.Conn, username, password string) (net.Conn, error) {
+	header := make([]byte, 2)
+	_, err := io.ReadFull(conn, header)
+	if err != nil {
+		return nil, err
+	}
+
+	methods := make([]byte, header[1])
+	_, err = io.ReadFull(conn, methods)
+	if err != nil {
+		return nil, err
+	}
+
+	if username == "" {
+		_, err = conn.Write([]byte{5, 0})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		_, err = conn.Write([]byte{5, 2})
+		if err != nil {
+			return nil, err
+		}
+
+		u, p, err := readSOCKS5Credentials(conn)
+		if err != nil {
+			return nil, err
+		}
+
+		if u != username || p != password {
+			conn.Write([]byte{1, 1})
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		_, err = conn.Write([]byte{1, 0})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req := make([]byte, 4)
+	_, err = io.ReadFull(conn, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var host string
+	switch req[3] {
+	case 1:
+		b := make([]byte, 4)
+		_, err = io.ReadFull(conn, b)
+		host = net.IP(b).String()
+	case 3:
+		l := make([]byte, 1)
+		_, err = io.ReadFull(conn, l)
+		if err == nil {
+			b := make([]byte, l[0])
+			_, err = io.ReadFull(conn, b)
+			host = string(b)
+		}
+	default:
+		b := make([]byte, 16)
+		_, err = io.ReadFull(conn, b)
+		host = net.IP(b).String()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	port := make([]byte, 2)
+	_, err = io.ReadFull(conn, port)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return nil, err
+	}
+
+	_, err = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	if err != nil {
+		target.Close()
+		return nil, err
+	}
+
+	return target, nil
+}
+
+func readSOCKS5Credentials(conn net.Conn) (string, string, error) {
+	b := make([]byte, 2)
+	_, err := io.ReadFull(conn, b)
+	if err != nil {
+		return "", "", err
+	}
+
+	u := make([]byte, b[1])
+	_, err = io.ReadFull(conn, u)
+	if err != nil {
+		return "", "", err
+	}
+
+	_, err = io.ReadFull(conn, b[:1])
+	if err != nil {
+		return "", "", err
+	}
+
+	p := make([]byte, b[0])
+	_, err = io.ReadFull(conn, p)
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(u), string(p), nil
+}
+
+// newTestHTTPConnectProxy starts a HTTP proxy supporting the CONNECT method and basic auth
+func newTestHTTPConnectProxy(t *testing.T, username, password string) *testProxy {
+	p := &testProxy{}
+	expectedAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT expected", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.Header.Get("Proxy-Authorization") != expectedAuth {
+			http.Error(w, "invalid credentials", http.StatusProxyAuthRequired)
+			return
+		}
+
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer target.Close()
+
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		_, err = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		if err != nil {
+			return
+		}
+
+		p.connections.Add(1)
+		pipe(conn, target)
+	}))
+	t.Cleanup(s.Close)
+
+	p.addr = s.Listener.Addr().String()
+	return p
+}
+
+func pipe(a, b net.Conn) {
+	done := make(chan struct{})
+	go func() {
+		io.Copy(a, b)
+		a.Close()
+		close(done)
+	}()
+
+	io.Copy(b, a)
+	b.Close()
+	<-done
+}
@@ -0,0 +1,210 @@
+// SPDX-License-Identifier: MIT
+
+package connector
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/xml"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// testSSHServer is a minimal SSH server accepting password authentication, exec requests and direct-tcpip channels
+type testSSHServer struct {
+	listener net.Listener
+	cfg      *ssh.ServerConfig
+	output   func(cmd string) string
+	conns    []net.Conn
+	mu       sync.Mutex
+}
+
+func newTestSSHServer(t *testing.T, output func(cmd string) string) *testSSHServer {
+	hostKey, _ := generateSigner(t)
+
+	cfg := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			return &ssh.Permissions{}, nil
+		},
+	}
+	cfg.AddHostKey(hostKey)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &testSSHServer{
+		listener: l,
+		cfg:      cfg,
+		output:   output,
+	}
+	t.Cleanup(s.Close)
+
+	go s.serve()
+
+	return s
+}
+
+func (s *testSSHServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops the server and closes all connections
+func (s *testSSHServer) Close() {
+	s.listener.Close()
+	s.CloseConnections()
+}
+
+// CloseConnections closes all connections to the server
+func (s *testSSHServer) CloseConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.conns {
+		c.Close()
+	}
+
+	s.conns = nil
+}
+
+func (s *testSSHServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+
+		go s.handleConn(conn)
+	}
+}
+
+func (s *testSSHServer) handleConn(conn net.Conn) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, s.cfg)
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	go ssh.DiscardRequests(reqs)
+
+	for ch := range chans {
+		switch ch.ChannelType() {
+		case "session":
+			go s.handleSession(ch)
+		case "direct-tcpip":
+			go handleDirectTCPIP(ch)
+		default:
+			ch.Reject(ssh.UnknownChannelType, "unsupported channel type")
+		}
+	}
+}
+
+func (s *testSSHServer) handleSession(newCh ssh.NewChannel) {
+	ch, reqs, err := newCh.Accept()
+	if err != nil {
+		return
+	}
+	defer ch.Close()
+
+	for req := range reqs {
+		if req.Type == "subsystem" && string(req.Payload[4:]) == netconfSubsystem {
+			req.Reply(true, nil)
+			go ssh.DiscardRequests(reqs)
+			s.serveNetconf(ch)
+			return
+		}
+
+		if req.Type != "exec" {
+			req.Reply(false, nil)
+			continue
+		}
+
+		cmd := string(req.Payload[4:])
+		req.Reply(true, nil)
+
+		io.WriteString(ch, s.output(cmd))
+		ch.SendRequest("exit-status", false, binary.BigEndian.AppendUint32(nil, 0))
+		return
+	}
+}
+
+// serveNetconf answers the RPCs of a NETCONF session (base:1.0 framing) with the output for the RPC
+func (s *testSSHServer) serveNetconf(ch ssh.Channel) {
+	r := bufio.NewReader(ch)
+
+	_, err := readNetconfMessage(r, false)
+	if err != nil {
+		return
+	}
+
+	hello := `<hello xmlns="` + netconfNamespace + `"><capabilities><capability>` + netconfBase10 + `</capability></capabilities></hello>`
+	_ = writeNetconfMessage(ch, []byte(hello), false)
+
+	for {
+		b, err := readNetconfMessage(r, false)
+		if err != nil {
+			return
+		}
+
+		var rpc struct {
+			MessageID string `xml:"message-id,attr"`
+			Body      string `xml:",innerxml"`
+		}
+		err = xml.Unmarshal(b, &rpc)
+		if err != nil {
+			return
+		}
+
+		reply := `<rpc-reply message-id="` + rpc.MessageID + `">` + s.output(rpc.Body) + `</rpc-reply>`
+		err = writeNetconfMessage(ch, []byte(reply), false)
+		if err != nil {
+			return
+		}
+	}
+}
+
+func handleDirectTCPIP(newCh ssh.NewChannel) {
+	var payload struct {
+		Host       string
+		Port       uint32
+		OriginHost string
+		OriginPort uint32
+	}
+
+	err := ssh.Unmarshal(newCh.ExtraData(), &payload)
+	if err != nil {
+		newCh.Reject(ssh.ConnectionFailed, "invalid payload")
+		return
+	}
+
+	target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+	if err != nil {
+		newCh.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+
+	ch, reqs, err := newCh.Accept()
+	if err != nil {
+		target.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	go func() {
+		io.Copy(ch, target)
+		ch.Close()
+	}()
+
+	io.Copy(target, ch)
+	target.Close()
+}
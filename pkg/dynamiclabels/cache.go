@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: MIT
+
+package dynamiclabels
+
+import (
+	"strings"
+	"sync"
+)
+
+// maxCachedLabelSets limits the number of label sets descriptions are cached for
+const maxCachedLabelSets = 1024
+
+// DescriptionCache reuses the descriptions created for a set of dynamic label names,
+// so they are not created again for every interface and scrape
+type DescriptionCache[T any] struct {
+	create func(Labels) T
+	mu     sync.RWMutex
+	items  map[string]T
+}
+
+// NewDescriptionCache creates a new cache creating missing descriptions using create
+func NewDescriptionCache[T any](create func(Labels) T) *DescriptionCache[T] {
+	return &DescriptionCache[T]{
+		create: create,
+		items:  make(map[string]T),
+	}
+}
+
+// For returns the descriptions for the names of the labels
+func (c *DescriptionCache[T]) For(labels Labels) T {
+	key := strings.Join(labels.Keys(), "\x00")
+
+	c.mu.RLock()
+	d, found := c.items[key]
+	c.mu.RUnlock()
+	if found {
+		return d
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if d, found := c.items[key]; found {
+		return d
+	}
+
+	d = c.create(labels)
+	if len(c.items) < maxCachedLabelSets {
+		c.items[key] = d
+	}
+
+	return d
+}
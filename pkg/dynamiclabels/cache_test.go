@@ -0,0 +1,25 @@
+// SPDX-License-Identifier: MIT
+
+package dynamiclabels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescriptionCache(t *testing.T) {
+	created := 0
+	c := NewDescriptionCache(func(labels Labels) []string {
+		created++
+		return labels.Keys()
+	})
+
+	assert.Equal(t, []string{"foo"}, c.For(Labels{New("foo", "x")}))
+	assert.Equal(t, []string{"foo"}, c.For(Labels{New("foo", "y")}))
+	assert.Equal(t, 1, created, "same label names")
+
+	assert.Equal(t, []string{"foo", "bar"}, c.For(Labels{New("foo", "x"), New("bar", "1")}))
+	assert.Equal(t, []string{}, c.For(nil))
+	assert.Equal(t, 3, created)
+}
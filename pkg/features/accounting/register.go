@@ -0,0 +1,15 @@
+// SPDX-License-Identifier: MIT
+
+package accounting
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "accounting",
+		Description: "accounting flow metrics",
+		New: func(collector.Options) collector.RPCCollector {
+			return NewCollector()
+		},
+	})
+}
@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: MIT
+
+package alarm
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "alarm",
+		Description: "Alarm metrics",
+		Default:     collector.EnabledInConfig,
+		Options:     []collector.Option{collector.OptionAlarmFilter},
+		New: func(opts collector.Options) collector.RPCCollector {
+			return NewCollector(opts.AlarmFilter)
+		},
+	})
+}
@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: MIT
+
+// Package all registers all built-in collectors (see collector.Register).
+// Further collectors can be compiled into the exporter by importing their package next to this one.
+package all
+
+import (
+	_ "github.com/czerwonk/junos_exporter/pkg/features/aaa"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/accounting"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/alarm"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/arp"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/bfd"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/bgp"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/ddosprotection"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/dot1x"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/environment"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/firewall"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/fpc"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/interfacediagnostics"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/interfacequeue"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/interfaces"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/ipsec"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/isis"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/krt"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/l2circuit"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/l2vpn"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/lacp"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/ldp"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/lldp"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/mac"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/macsec"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/mplslsp"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/nat"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/nat2"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/ntp"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/ospf"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/poe"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/power"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/route"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/routingengine"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/rpki"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/rpm"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/security"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/securityike"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/securitypolicies"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/storage"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/subscriber"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/system"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/systemstatistics"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/twamp"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/vpws"
+	_ "github.com/czerwonk/junos_exporter/pkg/features/vrrp"
+)
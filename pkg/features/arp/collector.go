@@ -5,6 +5,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
 )
 
 const prefix string = "junos_arp_"
@@ -18,10 +19,24 @@ func init() {
 	arpEntriesCountDesc = prometheus.NewDesc(prefix+"entries", "Amount of ARP entries on an interface", l, nil)
 }
 
-type arpCollector struct{}
+type arpCollector struct {
+	logicalSystem string
+	instance      string
+	filter        *interfacefilter.Filter
+}
+
+// NewCollector creates a new collector (showing the ARP entries of the routing instance if set)
+func NewCollector(logicalSystem, instance string, filter *interfacefilter.Filter) collector.RPCCollector {
+	return &arpCollector{logicalSystem: logicalSystem, instance: instance, filter: filter}
+}
 
-func NewCollector() collector.RPCCollector {
-	return &arpCollector{}
+func (c *arpCollector) command() string {
+	cmd := "show arp no-resolve"
+	if c.instance != "" {
+		cmd += " vpn " + c.instance
+	}
+
+	return collector.LogicalSystemCommand(cmd, c.logicalSystem)
 }
 
 func (c *arpCollector) Name() string {
@@ -34,13 +49,17 @@ func (c *arpCollector) Describe(ch chan<- *prometheus.Desc) {
 
 func (c *arpCollector) Collect(client collector.Client, ch chan<- prometheus.Metric, labelValues []string) error {
 	var res results
-	err := client.RunCommandAndParse("show arp no-resolve", &res)
+	err := client.RunCommandAndParse(c.command(), &res)
 	if err != nil {
 		return errors.Wrap(err, "failed to run command 'show arp no-resolve'")
 	}
 
 	interfaces := make(map[string]float64)
 	for _, a := range res.ArpTableInformation.ArpTableEntry {
+		if !c.filter.Matches(a.InterfaceName) {
+			continue
+		}
+
 		interfaces[a.InterfaceName] += 1
 	}
 
@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: MIT
+
+package arp
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
+)
+
+func TestCollectAppliesInterfaceFilter(t *testing.T) {
+	f, err := interfacefilter.New([]string{"^xe-"}, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cl := &fakeClient{response: `
+<rpc-reply>
+    <arp-table-information>
+        <arp-table-entry>
+            <ip-address>192.0.2.1</ip-address>
+            <interface-name>xe-0/0/0.0</interface-name>
+        </arp-table-entry>
+        <arp-table-entry>
+            <ip-address>192.0.2.2</ip-address>
+            <interface-name>xe-0/0/0.0</interface-name>
+        </arp-table-entry>
+        <arp-table-entry>
+            <ip-address>192.0.2.3</ip-address>
+            <interface-name>irb.100</interface-name>
+        </arp-table-entry>
+    </arp-table-information>
+</rpc-reply>`}
+
+	expected := `
+# HELP junos_arp_entries Amount of ARP entries on an interface
+# TYPE junos_arp_entries gauge
+junos_arp_entries{interface="xe-0/0/0.0",target="router1"} 2
+`
+	c := &testCollector{c: NewCollector("", "", f), cl: cl}
+	assert.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expected)))
+}
+
+type testCollector struct {
+	c  collector.RPCCollector
+	cl collector.Client
+}
+
+func (t *testCollector) Describe(ch chan<- *prometheus.Desc) {
+	t.c.Describe(ch)
+}
+
+func (t *testCollector) Collect(ch chan<- prometheus.Metric) {
+	_ = t.c.Collect(t.cl, ch, []string{"router1"})
+}
+
+type fakeClient struct {
+	collector.Client
+	response string
+}
+
+func (c *fakeClient) RunCommandAndParse(cmd string, obj interface{}) error {
+	return xml.Unmarshal([]byte(c.response), obj)
+}
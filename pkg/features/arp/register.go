@@ -0,0 +1,18 @@
+// SPDX-License-Identifier: MIT
+
+package arp
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "arp",
+		Flag:        "arps.enabled",
+		Description: "ARP metrics",
+		Default:     collector.EnabledByFlag,
+		Options:     []collector.Option{collector.OptionLogicalSystem, collector.OptionRoutingInstance, collector.OptionInterfaceFilter},
+		New: func(opts collector.Options) collector.RPCCollector {
+			return NewCollector(opts.LogicalSystem, opts.RoutingInstance, opts.InterfaceFilter)
+		},
+	})
+}
@@ -4,6 +4,7 @@ package bfd
 
 import (
 	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -23,6 +24,8 @@ func init() {
 }
 
 type bfdCollector struct {
+	logicalSystem string
+	filter        *interfacefilter.Filter
 }
 
 // Name returns the name of the collector
@@ -31,8 +34,8 @@ func (*bfdCollector) Name() string {
 }
 
 // NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &bfdCollector{}
+func NewCollector(logicalSystem string, filter *interfacefilter.Filter) collector.RPCCollector {
+	return &bfdCollector{logicalSystem: logicalSystem, filter: filter}
 }
 
 // Describe describes the metrics
@@ -43,12 +46,16 @@ func (*bfdCollector) Describe(ch chan<- *prometheus.Desc) {
 // Collect collects metrics from JunOS
 func (c *bfdCollector) Collect(client collector.Client, ch chan<- prometheus.Metric, labelValues []string) error {
 	var res = result{}
-	err := client.RunCommandAndParse("show bfd session extensive", &res)
+	err := client.RunCommandAndParse(collector.LogicalSystemCommand("show bfd session extensive", c.logicalSystem), &res)
 	if err != nil {
 		return err
 	}
 
 	for _, bfds := range res.Information.BfdSessions {
+		if !c.filter.Matches(bfds.Interface) {
+			continue
+		}
+
 		l := append(labelValues, bfds.Neighbor, bfds.Interface, bfds.Client.Name)
 		ch <- prometheus.MustNewConstMetric(bfdState, prometheus.GaugeValue, float64(bfdStateMap[bfds.State]), l...)
 	}
@@ -0,0 +1,16 @@
+// SPDX-License-Identifier: MIT
+
+package bfd
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "bfd",
+		Description: "BFD metrics",
+		Options:     []collector.Option{collector.OptionLogicalSystem, collector.OptionInterfaceFilter},
+		New: func(opts collector.Options) collector.RPCCollector {
+			return NewCollector(opts.LogicalSystem, opts.InterfaceFilter)
+		},
+	})
+}
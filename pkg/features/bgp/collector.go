@@ -12,6 +12,7 @@ import (
 
 	"github.com/czerwonk/junos_exporter/pkg/collector"
 	"github.com/czerwonk/junos_exporter/pkg/dynamiclabels"
+	"github.com/czerwonk/junos_exporter/pkg/routinginstance"
 )
 
 const prefix string = "junos_bgp_session_"
@@ -35,6 +36,9 @@ type description struct {
 	holdTimeDesc                *prometheus.Desc
 }
 
+// descriptions are the descriptions by dynamic label names
+var descriptions = dynamiclabels.NewDescriptionCache(newDescriptions)
+
 func newDescriptions(dynLabels dynamiclabels.Labels) *description {
 	d := &description{}
 
@@ -68,15 +72,18 @@ func newDescriptions(dynLabels dynamiclabels.Labels) *description {
 type bgpCollector struct {
 	LogicalSystem string
 	descriptionRe *regexp.Regexp
+	instances     *routinginstance.Filter
 }
 
 type groupMap map[int64]group
 
-// NewCollector creates a new collector
-func NewCollector(logicalSystem string, descRe *regexp.Regexp) collector.RPCCollector {
+// NewCollector creates a new collector.
+// If instances is set, sessions have a routing_instance label and only sessions of routing instances selected by the filter are exported.
+func NewCollector(logicalSystem string, descRe *regexp.Regexp, instances *routinginstance.Filter) collector.RPCCollector {
 	return &bgpCollector{
 		LogicalSystem: logicalSystem,
 		descriptionRe: descRe,
+		instances:     instances,
 	}
 }
 
@@ -87,7 +94,7 @@ func (*bgpCollector) Name() string {
 
 // Describe describes the metrics
 func (*bgpCollector) Describe(ch chan<- *prometheus.Desc) {
-	d := newDescriptions(nil)
+	d := descriptions.For(nil)
 	ch <- d.upDesc
 	ch <- d.receivedPrefixesDesc
 	ch <- d.acceptedPrefixesDesc
@@ -162,6 +169,11 @@ func (c *bgpCollector) collect(client collector.Client, ch chan<- prometheus.Met
 }
 
 func (c *bgpCollector) collectForPeer(p peer, groups groupMap, ch chan<- prometheus.Metric, labelValues []string) {
+	instance := routinginstance.Name(p.CFGRTI)
+	if !c.instances.Matches(instance) {
+		return
+	}
+
 	ip := strings.Split(p.IP, "+")
 	lv := append(labelValues, []string{
 		p.ASN,
@@ -180,9 +192,13 @@ func (c *bgpCollector) collectForPeer(p peer, groups groupMap, ch chan<- prometh
 			dynamiclabels.New("interface", p.LocalInterfaceName))
 	}
 
+	if c.instances != nil {
+		dynLabels = append(dynLabels, dynamiclabels.New("routing_instance", instance))
+	}
+
 	lv = append(lv, dynLabels.Values()...)
 
-	d := newDescriptions(dynLabels)
+	d := descriptions.For(dynLabels)
 
 	ch <- prometheus.MustNewConstMetric(d.upDesc, prometheus.GaugeValue, float64(up), lv...)
 	ch <- prometheus.MustNewConstMetric(d.stateDesc, prometheus.GaugeValue, bgpStateToNumber(p.State), lv...)
@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: MIT
+
+package bgp
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "bgp",
+		Description: "BGP metrics",
+		Default:     collector.Enabled,
+		Options:     []collector.Option{collector.OptionLogicalSystem, collector.OptionInterfaceDescriptionRegex, collector.OptionRoutingInstanceFilter},
+		New: func(opts collector.Options) collector.RPCCollector {
+			return NewCollector(opts.LogicalSystem, opts.InterfaceDescriptionRegex, opts.RoutingInstanceFilter)
+		},
+	})
+}
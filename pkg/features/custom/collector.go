@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: MIT
+
+package custom
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/czerwonk/junos_exporter/pkg/collector"
+)
+
+type customCollector struct {
+	name string
+	def  *Definition
+}
+
+// NewCollector creates a new collector for the (loaded) definition
+func NewCollector(name string, def *Definition) collector.RPCCollector {
+	return &customCollector{
+		name: name,
+		def:  def,
+	}
+}
+
+// Name returns the name of the collector
+func (c *customCollector) Name() string {
+	return c.name
+}
+
+// Describe describes the metrics
+func (c *customCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.def.Metrics {
+		ch <- m.desc
+	}
+}
+
+// Collect collects metrics from JunOS
+func (c *customCollector) Collect(client collector.Client, ch chan<- prometheus.Metric, labelValues []string) error {
+	var doc *node
+	err := client.RunCommandAndParseWithParser(c.def.Command, func(b []byte) error {
+		var err error
+		doc, err = parseXML(b)
+		return err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to run command '%s'", c.def.Command)
+	}
+
+	for _, r := range c.def.records.nodes(replyRoot(doc)) {
+		c.collectForRecord(r, ch, labelValues)
+	}
+
+	return nil
+}
+
+// replyRoot returns the rpc-reply element (paths of records are relative to it)
+func replyRoot(doc *node) *node {
+	for _, n := range doc.children {
+		if n.name == "rpc-reply" {
+			return n
+		}
+	}
+
+	return doc
+}
+
+func (c *customCollector) collectForRecord(r *node, ch chan<- prometheus.Metric, labelValues []string) {
+	l := append([]string{}, labelValues...)
+	for _, p := range c.def.labelPaths {
+		v, _ := p.value(r)
+		l = append(l, v)
+	}
+
+	for _, m := range c.def.Metrics {
+		v, found := m.path.value(r)
+		if !found {
+			continue
+		}
+
+		value, ok := m.parse(v)
+		if !ok {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(m.desc, m.valueType(), value*m.Scale, l...)
+	}
+}
+
+// parse converts the text of the value to a number (using the enum mapping if defined)
+func (m *MetricDefinition) parse(s string) (float64, bool) {
+	if v, found := m.Enum[s]; found {
+		return v, true
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return v, true
+}
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: MIT
+
+package custom
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/rpc"
+)
+
+const definitionYAML = `
+command: show interfaces terse
+records: interface-information/physical-interface
+labels:
+  name: name
+metrics:
+  - name: custom_interface_up
+    help: Interface is up
+    path: oper-status
+    enum:
+      up: 1
+      down: 0
+  - name: custom_interface_speed_bits
+    path: speed
+    scale: 1000000
+`
+
+func TestCollect(t *testing.T) {
+	def := &Definition{}
+	require.NoError(t, yaml.Unmarshal([]byte(definitionYAML), def))
+	require.NoError(t, def.Load(nil))
+
+	c := NewCollector("interfaces_terse", def)
+	assert.Equal(t, "interfaces_terse", c.Name())
+
+	cl := &fakeClient{
+		responses: map[string]string{
+			"show interfaces terse": pathTestXML,
+		},
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(&testCollector{c: c, cl: cl})
+
+	expected := `
+# HELP junos_custom_interface_speed_bits Value of speed
+# TYPE junos_custom_interface_speed_bits gauge
+junos_custom_interface_speed_bits{name="ge-0/0/0",target="router1"} 1e+09
+# HELP junos_custom_interface_up Interface is up
+# TYPE junos_custom_interface_up gauge
+junos_custom_interface_up{name="ge-0/0/0",target="router1"} 1
+junos_custom_interface_up{name="ge-0/0/1",target="router1"} 0
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected)))
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		def  *Definition
+	}{
+		{
+			name: "missing command",
+			def:  &Definition{Metrics: []*MetricDefinition{{Name: "a", Path: "b"}}},
+		},
+		{
+			name: "no metrics",
+			def:  &Definition{Command: "show version"},
+		},
+		{
+			name: "invalid metric name",
+			def:  &Definition{Command: "show version", Metrics: []*MetricDefinition{{Name: "a-b", Path: "b"}}},
+		},
+		{
+			name: "invalid type",
+			def:  &Definition{Command: "show version", Metrics: []*MetricDefinition{{Name: "a", Path: "b", Type: "histogram"}}},
+		},
+		{
+			name: "reserved label",
+			def: &Definition{
+				Command: "show version",
+				Labels:  map[string]string{"target": "host-name"},
+				Metrics: []*MetricDefinition{{Name: "a", Path: "b"}},
+			},
+		},
+		{
+			name: "logical system label",
+			def: &Definition{
+				Command: "show version",
+				Labels:  map[string]string{"logical_system": "name"},
+				Metrics: []*MetricDefinition{{Name: "a", Path: "b"}},
+			},
+		},
+		{
+			name: "routing instance label",
+			def: &Definition{
+				Command: "show version",
+				Labels:  map[string]string{"routing_instance": "name"},
+				Metrics: []*MetricDefinition{{Name: "a", Path: "b"}},
+			},
+		},
+		{
+			name: "device label",
+			def: &Definition{
+				Command: "show version",
+				Labels:  map[string]string{"site": "name"},
+				Metrics: []*MetricDefinition{{Name: "a", Path: "b"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Error(t, test.def.Load([]string{"site"}))
+		})
+	}
+}
+
+type testCollector struct {
+	c  collector.RPCCollector
+	cl collector.Client
+}
+
+func (t *testCollector) Describe(ch chan<- *prometheus.Desc) {
+	t.c.Describe(ch)
+}
+
+func (t *testCollector) Collect(ch chan<- prometheus.Metric) {
+	_ = t.c.Collect(t.cl, ch, []string{"router1"})
+}
+
+type fakeClient struct {
+	collector.Client
+	responses map[string]string
+}
+
+func (c *fakeClient) RunCommandAndParseWithParser(cmd string, parser rpc.Parser) error {
+	return parser([]byte(c.responses[cmd]))
+}
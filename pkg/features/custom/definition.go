@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: MIT
+
+// Package custom provides collectors defined in the config file (command + XPath-like paths to metrics),
+// so metrics of further commands can be exported without writing a feature package.
+package custom
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const prefix = "junos_"
+
+const (
+	// TypeGauge exports the value as gauge (default)
+	TypeGauge = "gauge"
+
+	// TypeCounter exports the value as counter
+	TypeCounter = "counter"
+)
+
+var nameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// reservedLabelNames are the names of the labels added to the metrics by the exporter
+var reservedLabelNames = []string{"target", "logical_system", "routing_instance"}
+
+// Definition defines a collector running a command and exporting values of the reply as metrics
+type Definition struct {
+	// Command is the CLI command to run (e.g. "show krt queue")
+	Command string `yaml:"command"`
+
+	// Records selects the repeated elements to export metrics for (relative to rpc-reply, e.g. "krt-queue-information/krt-queue").
+	// If not set, the reply is a single record.
+	Records string `yaml:"records,omitempty"`
+
+	// Labels are the paths (relative to a record) to the values of the labels by label name
+	Labels map[string]string `yaml:"labels,omitempty"`
+
+	// Metrics are the values exported for each record
+	Metrics []*MetricDefinition `yaml:"metrics"`
+
+	records    *path
+	labelNames []string
+	labelPaths []*path
+}
+
+// MetricDefinition defines a metric exported for each record
+type MetricDefinition struct {
+	// Name of the metric (prefixed with junos_)
+	Name string `yaml:"name"`
+
+	// Help text of the metric
+	Help string `yaml:"help,omitempty"`
+
+	// Path to the value (relative to a record)
+	Path string `yaml:"path"`
+
+	// Type of the metric (gauge or counter)
+	Type string `yaml:"type,omitempty"`
+
+	// Scale is multiplied with the value (e.g. 0.001 to convert milliseconds to seconds)
+	Scale float64 `yaml:"scale,omitempty"`
+
+	// Enum maps string values (e.g. "up", "down") to numbers
+	Enum map[string]float64 `yaml:"enum,omitempty"`
+
+	path *path
+	desc *prometheus.Desc
+}
+
+// Load validates the definition and compiles its paths.
+// deviceLabels are the names of the labels configured for devices, which must not be used by the definition.
+func (d *Definition) Load(deviceLabels []string) error {
+	if d.Command == "" {
+		return fmt.Errorf("command is required")
+	}
+
+	if len(d.Metrics) == 0 {
+		return fmt.Errorf("at least one metric is required")
+	}
+
+	var err error
+	d.records, err = compilePath(d.Records)
+	if err != nil {
+		return fmt.Errorf("records: %w", err)
+	}
+
+	d.labelNames = []string{"target"}
+	d.labelPaths = nil
+
+	names := make([]string, 0, len(d.Labels))
+	for name := range d.Labels {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		if !nameRegex.MatchString(name) {
+			return fmt.Errorf("invalid label name %q", name)
+		}
+
+		if slices.Contains(reservedLabelNames, name) {
+			return fmt.Errorf("label name %q is reserved", name)
+		}
+
+		if slices.Contains(deviceLabels, name) {
+			return fmt.Errorf("label name %q is already used by the labels configured for devices", name)
+		}
+
+		p, err := compilePath(d.Labels[name])
+		if err != nil {
+			return fmt.Errorf("label %s: %w", name, err)
+		}
+
+		d.labelNames = append(d.labelNames, name)
+		d.labelPaths = append(d.labelPaths, p)
+	}
+
+	for _, m := range d.Metrics {
+		err := m.load(d.labelNames)
+		if err != nil {
+			return fmt.Errorf("metric %s: %w", m.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (m *MetricDefinition) load(labelNames []string) error {
+	if !nameRegex.MatchString(m.Name) {
+		return fmt.Errorf("invalid metric name %q", m.Name)
+	}
+
+	switch m.Type {
+	case "":
+		m.Type = TypeGauge
+	case TypeGauge, TypeCounter:
+	default:
+		return fmt.Errorf("invalid type %q (expected: %s or %s)", m.Type, TypeGauge, TypeCounter)
+	}
+
+	if m.Scale == 0 {
+		m.Scale = 1
+	}
+
+	p, err := compilePath(m.Path)
+	if err != nil {
+		return err
+	}
+	m.path = p
+
+	help := m.Help
+	if help == "" {
+		help = fmt.Sprintf("Value of %s", m.Path)
+	}
+	m.desc = prometheus.NewDesc(prefix+m.Name, help, labelNames, nil)
+
+	return nil
+}
+
+func (m *MetricDefinition) valueType() prometheus.ValueType {
+	if m.Type == TypeCounter {
+		return prometheus.CounterValue
+	}
+
+	return prometheus.GaugeValue
+}
@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: MIT
+
+package custom
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// node is an element of a parsed XML document (namespaces are ignored)
+type node struct {
+	name     string
+	attrs    map[string]string
+	text     strings.Builder
+	children []*node
+	parent   *node
+}
+
+// parseXML parses the document into a tree of nodes and returns the document node (parent of the root element)
+func parseXML(b []byte) (*node, error) {
+	doc := &node{}
+	current := doc
+
+	d := xml.NewDecoder(bytes.NewReader(b))
+	for {
+		t, err := d.Token()
+		if err == io.EOF {
+			return doc, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := t.(type) {
+		case xml.StartElement:
+			n := &node{
+				name:   t.Name.Local,
+				attrs:  make(map[string]string, len(t.Attr)),
+				parent: current,
+			}
+			for _, a := range t.Attr {
+				n.attrs[a.Name.Local] = a.Value
+			}
+
+			current.children = append(current.children, n)
+			current = n
+		case xml.EndElement:
+			if current.parent != nil {
+				current = current.parent
+			}
+		case xml.CharData:
+			current.text.Write(t)
+		}
+	}
+}
+
+// value returns the trimmed text of the node
+func (n *node) value() string {
+	return strings.TrimSpace(n.text.String())
+}
+
+// descendants returns all nodes below n (depth first)
+func (n *node) descendants() []*node {
+	nodes := make([]*node, 0)
+	for _, c := range n.children {
+		nodes = append(nodes, c)
+		nodes = append(nodes, c.descendants()...)
+	}
+
+	return nodes
+}
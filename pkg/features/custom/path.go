@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: MIT
+
+package custom
+
+import (
+	"fmt"
+	"strings"
+)
+
+// path is a compiled XPath-like expression selecting elements, their text or attributes relative to a node.
+//
+// Supported syntax:
+//   - name: child elements with the name (namespaces are ignored), * for all child elements
+//   - . and ..: the node itself and its parent
+//   - //: descendants at any depth (e.g. //bgp-peer)
+//   - @name: attribute of the selected elements (last step only)
+//   - [name='value'], [@name='value']: elements having a child element or attribute with the value
+type path struct {
+	steps     []step
+	attribute string
+}
+
+type step struct {
+	name       string
+	descendant bool
+	predicate  *predicate
+}
+
+type predicate struct {
+	name      string
+	attribute bool
+	value     string
+}
+
+// compilePath compiles the expression. An empty expression selects the node itself.
+func compilePath(expr string) (*path, error) {
+	p := &path{}
+
+	expr = strings.TrimSpace(expr)
+	descendant := false
+	if strings.HasPrefix(expr, "//") {
+		descendant = true
+		expr = expr[2:]
+	} else {
+		expr = strings.TrimPrefix(expr, "/")
+	}
+
+	if expr == "" {
+		return p, nil
+	}
+
+	parts := strings.Split(expr, "/")
+	for i, part := range parts {
+		if part == "" {
+			if descendant || i == len(parts)-1 {
+				return nil, fmt.Errorf("invalid path %q", expr)
+			}
+
+			descendant = true
+			continue
+		}
+
+		if strings.HasPrefix(part, "@") {
+			if i != len(parts)-1 || descendant {
+				return nil, fmt.Errorf("invalid path %q: attributes can only be selected in the last step", expr)
+			}
+
+			p.attribute = part[1:]
+			continue
+		}
+
+		s, err := compileStep(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %q: %w", expr, err)
+		}
+
+		s.descendant = descendant
+		descendant = false
+		p.steps = append(p.steps, s)
+	}
+
+	return p, nil
+}
+
+func compileStep(part string) (step, error) {
+	s := step{name: part}
+
+	i := strings.Index(part, "[")
+	if i < 0 {
+		return s, nil
+	}
+
+	if !strings.HasSuffix(part, "]") {
+		return s, fmt.Errorf("unterminated predicate in %q", part)
+	}
+
+	s.name = part[:i]
+	cond := part[i+1 : len(part)-1]
+
+	name, value, found := strings.Cut(cond, "=")
+	if !found {
+		return s, fmt.Errorf("predicate %q has to compare with a value", cond)
+	}
+
+	value = strings.TrimSpace(value)
+	if len(value) < 2 || (value[0] != '\'' && value[0] != '"') || value[len(value)-1] != value[0] {
+		return s, fmt.Errorf("value of predicate %q has to be quoted", cond)
+	}
+
+	pr := &predicate{
+		name:  strings.TrimSpace(name),
+		value: value[1 : len(value)-1],
+	}
+	if strings.HasPrefix(pr.name, "@") {
+		pr.attribute = true
+		pr.name = pr.name[1:]
+	}
+
+	s.predicate = pr
+	return s, nil
+}
+
+// nodes returns the elements selected by the path
+func (p *path) nodes(n *node) []*node {
+	nodes := []*node{n}
+
+	for _, s := range p.steps {
+		next := make([]*node, 0)
+		for _, c := range nodes {
+			next = append(next, s.apply(c)...)
+		}
+
+		nodes = next
+	}
+
+	return nodes
+}
+
+// value returns the text (or attribute) of the first element selected by the path
+func (p *path) value(n *node) (string, bool) {
+	for _, m := range p.nodes(n) {
+		if p.attribute == "" {
+			return m.value(), true
+		}
+
+		if v, found := m.attrs[p.attribute]; found {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+func (s step) apply(n *node) []*node {
+	switch s.name {
+	case ".":
+		return []*node{n}
+	case "..":
+		if n.parent == nil {
+			return nil
+		}
+
+		return []*node{n.parent}
+	}
+
+	candidates := n.children
+	if s.descendant {
+		candidates = n.descendants()
+	}
+
+	nodes := make([]*node, 0)
+	for _, c := range candidates {
+		if (s.name == "*" || c.name == s.name) && s.predicate.matches(c) {
+			nodes = append(nodes, c)
+		}
+	}
+
+	return nodes
+}
+
+func (pr *predicate) matches(n *node) bool {
+	if pr == nil {
+		return true
+	}
+
+	if pr.attribute {
+		return n.attrs[pr.name] == pr.value
+	}
+
+	for _, c := range n.children {
+		if c.name == pr.name && c.value() == pr.value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier: MIT
+
+package custom
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pathTestXML = `<rpc-reply xmlns:junos="http://xml.juniper.net/junos/21.4R0/junos">
+    <interface-information xmlns="http://xml.juniper.net/junos/21.4R0/junos-interface" junos:style="normal">
+        <physical-interface>
+            <name>ge-0/0/0</name>
+            <oper-status>up</oper-status>
+            <speed junos:format="1Gbps">1000</speed>
+        </physical-interface>
+        <physical-interface>
+            <name>ge-0/0/1</name>
+            <oper-status>down</oper-status>
+        </physical-interface>
+    </interface-information>
+</rpc-reply>`
+
+func TestPath(t *testing.T) {
+	doc, err := parseXML([]byte(pathTestXML))
+	require.NoError(t, err)
+	root := replyRoot(doc)
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected []string
+	}{
+		{
+			name:     "children",
+			expr:     "interface-information/physical-interface/name",
+			expected: []string{"ge-0/0/0", "ge-0/0/1"},
+		},
+		{
+			name:     "descendants",
+			expr:     "//name",
+			expected: []string{"ge-0/0/0", "ge-0/0/1"},
+		},
+		{
+			name:     "wildcard",
+			expr:     "*/physical-interface/oper-status",
+			expected: []string{"up", "down"},
+		},
+		{
+			name:     "predicate",
+			expr:     "//physical-interface[oper-status='down']/name",
+			expected: []string{"ge-0/0/1"},
+		},
+		{
+			name:     "parent",
+			expr:     "//speed/../name",
+			expected: []string{"ge-0/0/0"},
+		},
+		{
+			name:     "attribute",
+			expr:     "//speed/@format",
+			expected: []string{"1Gbps"},
+		},
+		{
+			name:     "no match",
+			expr:     "//mtu",
+			expected: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := compilePath(test.expr)
+			require.NoError(t, err)
+
+			values := make([]string, 0)
+			for _, n := range p.nodes(root) {
+				if p.attribute == "" {
+					values = append(values, n.value())
+				} else if v, found := n.attrs[p.attribute]; found {
+					values = append(values, v)
+				}
+			}
+
+			assert.Equal(t, test.expected, values)
+		})
+	}
+}
+
+func TestCompilePathInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"a/@b/c",
+		"a[b='c'",
+		"a[b]",
+		"a[b=c]",
+		"a/",
+	} {
+		_, err := compilePath(expr)
+		assert.Error(t, err, expr)
+	}
+}
@@ -0,0 +1,16 @@
+// SPDX-License-Identifier: MIT
+
+package ddosprotection
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "ddosprotection",
+		Feature:     "ddos_protection",
+		Description: "DDoS protection metrics",
+		New: func(collector.Options) collector.RPCCollector {
+			return NewCollector()
+		},
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -26,11 +27,13 @@ func init() {
 	currAuthVoipVlanDesc = prometheus.NewDesc(prefix+"authenticated_voip_vlan", "Interface dot1x Authenticated Voip Vlan", l, nil)
 }
 
-type dot1xCollector struct{}
+type dot1xCollector struct {
+	filter *interfacefilter.Filter
+}
 
 // NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &dot1xCollector{}
+func NewCollector(filter *interfacefilter.Filter) collector.RPCCollector {
+	return &dot1xCollector{filter: filter}
 }
 
 // Name returns the name of the collector
@@ -94,6 +97,10 @@ func (c *dot1xCollector) collect(client collector.Client, ch chan<- prometheus.M
 		return err
 	}
 	for _, dot1xInterface := range x.Results.Interfaces {
+		if !c.filter.Matches(dot1xInterface.InterfaceName) {
+			continue
+		}
+
 		c.collectForInterface(dot1xInterface, ch, labelValues)
 	}
 
@@ -0,0 +1,16 @@
+// SPDX-License-Identifier: MIT
+
+package dot1x
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "dot1x",
+		Description: "dot1x metrics",
+		Options:     []collector.Option{collector.OptionInterfaceFilter},
+		New: func(opts collector.Options) collector.RPCCollector {
+			return NewCollector(opts.InterfaceFilter)
+		},
+	})
+}
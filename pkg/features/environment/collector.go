@@ -4,12 +4,14 @@ package environment
 
 import (
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
 	"strings"
 
 	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/rpc"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -88,21 +90,20 @@ func (c *environmentCollector) environmentItems(client collector.Client, ch chan
 		return parseXML(b, &x)
 	})
 	if err != nil {
-		return nil
+		return err
 	}
 
 	if client.IsSatelliteEnabled() {
 		var y = multiEngineResult{}
 		err = client.RunCommandAndParseWithParser("show chassis environment satellite", func(b []byte) error {
-			if string(b[:]) == "\nerror: syntax error, expecting <command>: satellite\n" {
-				log.Printf("system doesn't seem to have satellite enabled")
-				return nil
-			}
-
 			return parseXML(b, &y)
 		})
-		if err != nil {
-			return nil
+
+		var unsupportedErr *rpc.UnsupportedCommandError
+		if errors.As(err, &unsupportedErr) {
+			log.Printf("system doesn't seem to have satellite enabled")
+		} else if err != nil {
+			return err
 		}
 
 		if len(y.Results.RoutingEngines) > 0 {
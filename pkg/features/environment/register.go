@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: MIT
+
+package environment
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "env",
+		Feature:     "environment",
+		Description: "environment metrics",
+		Default:     collector.Enabled,
+		New: func(collector.Options) collector.RPCCollector {
+			return NewCollector()
+		},
+	})
+}
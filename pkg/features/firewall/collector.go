@@ -26,11 +26,12 @@ func init() {
 }
 
 type firewallCollector struct {
+	logicalSystem string
 }
 
 // NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &firewallCollector{}
+func NewCollector(logicalSystem string) collector.RPCCollector {
+	return &firewallCollector{logicalSystem: logicalSystem}
 }
 
 // Name returns the name of the collector
@@ -49,7 +50,7 @@ func (*firewallCollector) Describe(ch chan<- *prometheus.Desc) {
 // Collect collects metrics from JunOS
 func (c *firewallCollector) Collect(client collector.Client, ch chan<- prometheus.Metric, labelValues []string) error {
 	var x = result{}
-	err := client.RunCommandAndParse("show firewall filter regex .*", &x)
+	err := client.RunCommandAndParse(collector.LogicalSystemCommand("show firewall filter regex .*", c.logicalSystem), &x)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: MIT
+
+package firewall
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "firewall",
+		Description: "Firewall count metrics",
+		Default:     collector.Enabled,
+		Options:     []collector.Option{collector.OptionLogicalSystem},
+		New: func(opts collector.Options) collector.RPCCollector {
+			return NewCollector(opts.LogicalSystem)
+		},
+	})
+}
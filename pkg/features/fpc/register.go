@@ -0,0 +1,16 @@
+// SPDX-License-Identifier: MIT
+
+package fpc
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "fpc",
+		Description: "line card metrics",
+		Default:     collector.EnabledByFlag,
+		New: func(collector.Options) collector.RPCCollector {
+			return NewCollector()
+		},
+	})
+}
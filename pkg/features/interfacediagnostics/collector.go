@@ -4,6 +4,7 @@ package interfacediagnostics
 
 import (
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"log"
 	"regexp"
@@ -11,6 +12,8 @@ import (
 
 	"github.com/czerwonk/junos_exporter/pkg/collector"
 	"github.com/czerwonk/junos_exporter/pkg/dynamiclabels"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
+	"github.com/czerwonk/junos_exporter/pkg/rpc"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -57,6 +60,9 @@ type description struct {
 	transceiverDesc                              *prometheus.Desc
 }
 
+// descriptions are the descriptions by dynamic label names
+var descriptions = dynamiclabels.NewDescriptionCache(newDescriptions)
+
 func newDescriptions(dynLabels dynamiclabels.Labels) *description {
 	d := &description{}
 
@@ -116,12 +122,14 @@ func newDescriptions(dynLabels dynamiclabels.Labels) *description {
 
 type interfaceDiagnosticsCollector struct {
 	descriptionRe *regexp.Regexp
+	filter        *interfacefilter.Filter
 }
 
-// NewCollector creates a new collector
-func NewCollector(descriptionRe *regexp.Regexp) collector.RPCCollector {
+// NewCollector creates a new collector (a nil filter selects all interfaces)
+func NewCollector(descriptionRe *regexp.Regexp, filter *interfacefilter.Filter) collector.RPCCollector {
 	c := &interfaceDiagnosticsCollector{
 		descriptionRe: descriptionRe,
+		filter:        filter,
 	}
 
 	return c
@@ -134,7 +142,7 @@ func (*interfaceDiagnosticsCollector) Name() string {
 
 // Describe describes the metrics
 func (c *interfaceDiagnosticsCollector) Describe(ch chan<- *prometheus.Desc) {
-	d := newDescriptions(nil)
+	d := descriptions.For(nil)
 
 	ch <- d.laserBiasCurrentDesc
 	ch <- d.laserBiasCurrentHighAlarmThresholdDesc
@@ -204,6 +212,10 @@ func (c *interfaceDiagnosticsCollector) Collect(client collector.Client, ch chan
 	}
 
 	for _, diag := range diagnostics {
+		if !c.filter.Matches(diag.Name) {
+			continue
+		}
+
 		index := strings.Split(diag.Name, "-")[1]
 		diagnosticsDict[index] = diag
 
@@ -214,7 +226,7 @@ func (c *interfaceDiagnosticsCollector) Collect(client collector.Client, ch chan
 		}
 
 		dynLabels := dynamiclabels.ParseDescription(desc, c.descriptionRe)
-		d := newDescriptions(dynLabels)
+		d := descriptions.For(dynLabels)
 
 		l := append(labelValues, diag.Name)
 		l = append(l, dynLabels.Values()...)
@@ -372,9 +384,13 @@ func (c *interfaceDiagnosticsCollector) createTransceiverMetrics(client collecto
 			t.Name = "slot-" + t.Name
 		}
 
+		if !c.filter.Matches(t.Name) {
+			continue
+		}
+
 		transceiver_labels := append(labelValues, t.Name, chassisInfo.SerialNumber, chassisInfo.Description, port_speed, t.PicPort.FiberMode, strings.TrimSpace(t.PicPort.SFPVendorName), strings.TrimSpace(t.PicPort.SFPVendorPno), t.PicPort.Wavelength)
 
-		d := newDescriptions(nil)
+		d := descriptions.For(nil)
 
 		ch <- prometheus.MustNewConstMetric(d.transceiverDesc, prometheus.GaugeValue, oper_status, transceiver_labels...)
 	}
@@ -405,12 +421,6 @@ func (c *interfaceDiagnosticsCollector) interfaceDiagnosticsSatellite(client col
 			tmpByte   []byte
 		)
 
-		// check if satellite is enabled
-		if string(b[:]) == "\nerror: syntax error, expecting <command>: satellite\n" {
-			log.Printf("system doesn't seem to have satellite enabled")
-			return nil
-		}
-
 		for lineIndex = range lines {
 			if lineIndex == 0 {
 				// add good lines to new byte buffer
@@ -432,6 +442,12 @@ func (c *interfaceDiagnosticsCollector) interfaceDiagnosticsSatellite(client col
 		return xml.Unmarshal(tmpByte, &x)
 	})
 
+	var unsupportedErr *rpc.UnsupportedCommandError
+	if errors.As(err, &unsupportedErr) {
+		log.Printf("system doesn't seem to have satellite enabled")
+		return nil, nil
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -441,7 +457,7 @@ func (c *interfaceDiagnosticsCollector) interfaceDiagnosticsSatellite(client col
 
 func (c *interfaceDiagnosticsCollector) interfaceDiagnostics(client collector.Client) ([]*interfaceDiagnostics, error) {
 	var x = result{}
-	err := client.RunCommandAndParse("show interfaces diagnostics optics", &x)
+	err := client.RunCommandAndParse(c.filter.Command("show interfaces diagnostics optics"), &x)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,19 @@
+// SPDX-License-Identifier: MIT
+
+package interfacediagnostics
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "ifacediag",
+		Feature:     "interface_diagnostic",
+		Flag:        "ifdiag.enabled",
+		Description: "optical interface diagnostic metrics",
+		Default:     collector.Enabled,
+		Options:     []collector.Option{collector.OptionInterfaceDescriptionRegex, collector.OptionInterfaceFilter},
+		New: func(opts collector.Options) collector.RPCCollector {
+			return NewCollector(opts.InterfaceDescriptionRegex, opts.InterfaceFilter)
+		},
+	})
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/czerwonk/junos_exporter/pkg/collector"
 	"github.com/czerwonk/junos_exporter/pkg/dynamiclabels"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -34,6 +35,9 @@ type description struct {
 	totalDropBytes       *prometheus.Desc
 }
 
+// descriptions are the descriptions by dynamic label names
+var descriptions = dynamiclabels.NewDescriptionCache(newDescriptions)
+
 func newDescriptions(dynLabels dynamiclabels.Labels) *description {
 	d := &description{}
 
@@ -65,10 +69,11 @@ func newDescriptions(dynLabels dynamiclabels.Labels) *description {
 	return d
 }
 
-// NewCollector creates an queue collector instance
-func NewCollector(descRe *regexp.Regexp) collector.RPCCollector {
+// NewCollector creates an queue collector instance (a nil filter selects all interfaces)
+func NewCollector(descRe *regexp.Regexp, filter *interfacefilter.Filter) collector.RPCCollector {
 	c := &interfaceQueueCollector{
 		descriptionRe: descRe,
+		filter:        filter,
 	}
 
 	return c
@@ -76,6 +81,7 @@ func NewCollector(descRe *regexp.Regexp) collector.RPCCollector {
 
 type interfaceQueueCollector struct {
 	descriptionRe *regexp.Regexp
+	filter        *interfacefilter.Filter
 }
 
 // Name returns the name of the collector
@@ -85,7 +91,7 @@ func (*interfaceQueueCollector) Name() string {
 
 // Describe describes the metrics
 func (c *interfaceQueueCollector) Describe(ch chan<- *prometheus.Desc) {
-	d := newDescriptions(nil)
+	d := descriptions.For(nil)
 	ch <- d.queuedBytes
 	ch <- d.queuedPackets
 	ch <- d.transferedBytes
@@ -111,12 +117,16 @@ func (c *interfaceQueueCollector) Describe(ch chan<- *prometheus.Desc) {
 func (c *interfaceQueueCollector) Collect(client collector.Client, ch chan<- prometheus.Metric, labelValues []string) error {
 	q := result{}
 
-	err := client.RunCommandAndParse("show interfaces queue", &q)
+	err := client.RunCommandAndParse(c.filter.Command("show interfaces queue"), &q)
 	if err != nil {
 		return err
 	}
 
 	for _, iface := range q.InterfaceInformation.Interfaces {
+		if !c.filter.Matches(iface.Name) {
+			continue
+		}
+
 		c.collectForInterface(iface, ch, labelValues)
 	}
 
@@ -137,7 +147,7 @@ func (c *interfaceQueueCollector) collectForQueue(queue queue, ch chan<- prometh
 	l = append(l, queue.ForwaringClassName)
 	l = append(l, dynLabels.Values()...)
 
-	d := newDescriptions(dynLabels)
+	d := descriptions.For(dynLabels)
 	ch <- prometheus.MustNewConstMetric(d.queuedPackets, prometheus.CounterValue, float64(queue.QueuedPackets), l...)
 	ch <- prometheus.MustNewConstMetric(d.queuedBytes, prometheus.CounterValue, float64(queue.QueuedBytes), l...)
 	ch <- prometheus.MustNewConstMetric(d.transferedPackets, prometheus.CounterValue, float64(queue.TransferedPackets), l...)
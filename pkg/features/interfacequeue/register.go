@@ -0,0 +1,19 @@
+// SPDX-License-Identifier: MIT
+
+package interfacequeue
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "ifacequeue",
+		Feature:     "interface_queue",
+		Flag:        "queues.enabled",
+		Description: "interface queue metrics",
+		Default:     collector.EnabledInConfig,
+		Options:     []collector.Option{collector.OptionInterfaceDescriptionRegex, collector.OptionInterfaceFilter},
+		New: func(opts collector.Options) collector.RPCCollector {
+			return NewCollector(opts.InterfaceDescriptionRegex, opts.InterfaceFilter)
+		},
+	})
+}
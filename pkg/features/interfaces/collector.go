@@ -11,6 +11,7 @@ import (
 
 	"github.com/czerwonk/junos_exporter/pkg/collector"
 	"github.com/czerwonk/junos_exporter/pkg/dynamiclabels"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
 )
 
 const prefix = "junos_interface_"
@@ -57,6 +58,9 @@ type description struct {
 	fecModeDesc                 *prometheus.Desc
 }
 
+// descriptions are the descriptions by dynamic label names
+var descriptions = dynamiclabels.NewDescriptionCache(newDescriptions)
+
 func newDescriptions(dynLabels dynamiclabels.Labels) *description {
 	d := &description{}
 	l := []string{"target", "name", "description", "mac"}
@@ -107,12 +111,14 @@ func newDescriptions(dynLabels dynamiclabels.Labels) *description {
 // Collector collects interface metrics
 type interfaceCollector struct {
 	descriptionRe *regexp.Regexp
+	filter        *interfacefilter.Filter
 }
 
-// NewCollector creates a new collector
-func NewCollector(descRe *regexp.Regexp) collector.RPCCollector {
+// NewCollector creates a new collector (a nil filter selects all interfaces)
+func NewCollector(descRe *regexp.Regexp, filter *interfacefilter.Filter) collector.RPCCollector {
 	c := &interfaceCollector{
 		descriptionRe: descRe,
+		filter:        filter,
 	}
 
 	return c
@@ -125,7 +131,7 @@ func (*interfaceCollector) Name() string {
 
 // Describe describes the metrics
 func (*interfaceCollector) Describe(ch chan<- *prometheus.Desc) {
-	d := newDescriptions(nil)
+	d := descriptions.For(nil)
 	ch <- d.receiveBytesDesc
 	ch <- d.receivePacketsDesc
 	ch <- d.receiveErrorsDesc
@@ -175,6 +181,10 @@ func (c *interfaceCollector) Collect(client collector.Client, ch chan<- promethe
 	}
 
 	for _, s := range stats {
+		if !c.filter.Matches(s.Name) {
+			continue
+		}
+
 		c.collectForInterface(s, ch, labelValues)
 	}
 
@@ -183,7 +193,7 @@ func (c *interfaceCollector) Collect(client collector.Client, ch chan<- promethe
 
 func (c *interfaceCollector) interfaceStats(client collector.Client) ([]*interfaceStats, error) {
 	var x = result{}
-	err := client.RunCommandAndParse("show interfaces extensive", &x)
+	err := client.RunCommandAndParse(c.filter.Command("show interfaces extensive"), &x)
 	if err != nil {
 		return nil, err
 	}
@@ -275,7 +285,7 @@ func (c *interfaceCollector) collectForInterface(s *interfaceStats, ch chan<- pr
 	lv := append(labelValues, []string{s.Name, s.Description, s.Mac}...)
 	dynLabels := dynamiclabels.ParseDescription(s.Description, c.descriptionRe)
 	lv = append(lv, dynLabels.Values()...)
-	d := newDescriptions(dynLabels)
+	d := descriptions.For(dynLabels)
 
 	ch <- prometheus.MustNewConstMetric(d.receiveBytesDesc, prometheus.CounterValue, s.ReceiveBytes, lv...)
 	ch <- prometheus.MustNewConstMetric(d.receivePacketsDesc, prometheus.CounterValue, s.ReceivePackets, lv...)
@@ -0,0 +1,18 @@
+// SPDX-License-Identifier: MIT
+
+package interfaces
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "iface",
+		Feature:     "interfaces",
+		Description: "interface metrics",
+		Default:     collector.Enabled,
+		Options:     []collector.Option{collector.OptionInterfaceDescriptionRegex, collector.OptionInterfaceFilter},
+		New: func(opts collector.Options) collector.RPCCollector {
+			return NewCollector(opts.InterfaceDescriptionRegex, opts.InterfaceFilter)
+		},
+	})
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
+	"github.com/czerwonk/junos_exporter/pkg/routinginstance"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -57,11 +59,18 @@ func init() {
 }
 
 type isisCollector struct {
+	logicalSystem string
+	instance      string
+	filter        *interfacefilter.Filter
 }
 
-// NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &isisCollector{}
+// NewCollector creates a new collector (running its commands in the routing instance if set)
+func NewCollector(logicalSystem, instance string, filter *interfacefilter.Filter) collector.RPCCollector {
+	return &isisCollector{logicalSystem: logicalSystem, instance: instance, filter: filter}
+}
+
+func (c *isisCollector) command(cmd string) string {
+	return routinginstance.Command(collector.LogicalSystemCommand(cmd, c.logicalSystem), c.instance)
 }
 
 // Name returns the name of the collector
@@ -98,6 +107,10 @@ func (c *isisCollector) Collect(client collector.Client, ch chan<- prometheus.Me
 
 	if adjancies.Adjacencies != nil {
 		for _, adj := range adjancies.Adjacencies {
+			if !c.filter.Matches(adj.InterfaceName) {
+				continue
+			}
+
 			localLabelvalues := append(labelValues, adj.InterfaceName, adj.SystemName, strconv.Itoa(int(adj.Level)))
 			state := 0.0
 			switch adj.AdjacencyState {
@@ -120,21 +133,21 @@ func (c *isisCollector) Collect(client collector.Client, ch chan<- prometheus.Me
 	}
 
 	var ifas interfaces
-	err = client.RunCommandAndParse("show isis interface extensive", &ifas)
+	err = client.RunCommandAndParse(c.command("show isis interface extensive"), &ifas)
 	if err != nil {
 		return errors.Wrap(err, "failed to run command 'show isis interface extensive'")
 	}
 	c.isisInterfaces(ifas, ch, labelValues)
 
 	var coverage backupCoverage
-	err = client.RunCommandAndParse("show isis backup coverage", &coverage)
+	err = client.RunCommandAndParse(c.command("show isis backup coverage"), &coverage)
 	if err != nil {
 		return errors.Wrap(err, "failed to run command 'show isis backup coverage'")
 	}
 	c.isisBackupCoverage(coverage, ch, labelValues)
 
 	var backupPath backupSPF
-	err = client.RunCommandAndParse("show isis backup spf results", &backupPath)
+	err = client.RunCommandAndParse(c.command("show isis backup spf results"), &backupPath)
 	if err != nil {
 		return errors.Wrap(err, "failed to run command 'show isis backup spf results'")
 	}
@@ -147,7 +160,7 @@ func (c *isisCollector) isisAdjancies(client collector.Client) (*adjacencies, er
 	total := 0
 
 	var x = result{}
-	err := client.RunCommandAndParse("show isis adjacency", &x)
+	err := client.RunCommandAndParse(c.command("show isis adjacency"), &x)
 	if err != nil {
 		return nil, err
 	}
@@ -164,6 +177,10 @@ func (c *isisCollector) isisAdjancies(client collector.Client) (*adjacencies, er
 
 func (c *isisCollector) isisInterfaces(interfaces interfaces, ch chan<- prometheus.Metric, labelValues []string) {
 	for _, i := range interfaces.IsisInterfaceInformation.IsisInterface {
+		if !c.filter.Matches(i.InterfaceName) {
+			continue
+		}
+
 		if strings.ToLower(i.InterfaceLevelData.Passive) == "passive" {
 			continue
 		}
@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: MIT
+
+package isis
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "isis",
+		Description: "ISIS metrics",
+		Default:     collector.EnabledInConfig,
+		Options:     []collector.Option{collector.OptionLogicalSystem, collector.OptionRoutingInstance, collector.OptionInterfaceFilter},
+		New: func(opts collector.Options) collector.RPCCollector {
+			return NewCollector(opts.LogicalSystem, opts.RoutingInstance, opts.InterfaceFilter)
+		},
+	})
+}
@@ -54,11 +54,12 @@ func init() {
 
 // Collector collects L2CIRCUIT metrics
 type l2circuitCollector struct {
+	logicalSystem string
 }
 
 // NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &l2circuitCollector{}
+func NewCollector(logicalSystem string) collector.RPCCollector {
+	return &l2circuitCollector{logicalSystem: logicalSystem}
 }
 
 // Name returns the name of the collector
@@ -75,7 +76,7 @@ func (*l2circuitCollector) Describe(ch chan<- *prometheus.Desc) {
 // Collect collects metrics from JunOS
 func (c *l2circuitCollector) Collect(client collector.Client, ch chan<- prometheus.Metric, labelValues []string) error {
 	var x = result{}
-	err := client.RunCommandAndParse("show l2circuit connections brief", &x)
+	err := client.RunCommandAndParse(collector.LogicalSystemCommand("show l2circuit connections brief", c.logicalSystem), &x)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: MIT
+
+package l2circuit
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "l2c",
+		Feature:     "l2circuit",
+		Description: "l2circuit metrics",
+		Options:     []collector.Option{collector.OptionLogicalSystem},
+		New: func(opts collector.Options) collector.RPCCollector {
+			return NewCollector(opts.LogicalSystem)
+		},
+	})
+}
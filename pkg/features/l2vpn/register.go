@@ -0,0 +1,15 @@
+// SPDX-License-Identifier: MIT
+
+package l2vpn
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "l2vpn",
+		Description: "l2vpn metrics",
+		New: func(collector.Options) collector.RPCCollector {
+			return NewCollector()
+		},
+	})
+}
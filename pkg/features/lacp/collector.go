@@ -4,6 +4,7 @@ package lacp
 
 import (
 	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -27,6 +28,7 @@ func init() {
 }
 
 type lacpCollector struct {
+	filter *interfacefilter.Filter
 }
 
 // Name returns the name of the collector
@@ -35,8 +37,8 @@ func (*lacpCollector) Name() string {
 }
 
 // NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &lacpCollector{}
+func NewCollector(filter *interfacefilter.Filter) collector.RPCCollector {
+	return &lacpCollector{filter: filter}
 }
 
 // Describe describes the metrics
@@ -54,6 +56,10 @@ func (c *lacpCollector) Collect(client collector.Client, ch chan<- prometheus.Me
 
 	for _, iface := range x.Information.LacpInterfaces {
 		for _, member := range iface.LagLACPProtocols {
+			if !c.filter.Matches(member.Member) {
+				continue
+			}
+
 			l := append(labelValues, iface.LagLACPHeader.Name, member.Member)
 			ch <- prometheus.MustNewConstMetric(lacpMuxState, prometheus.GaugeValue, float64(lacpMuxStateMap[member.LacpMuxState]), l...)
 		}
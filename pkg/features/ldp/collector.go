@@ -4,6 +4,7 @@ package ldp
 
 import (
 	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/routinginstance"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -32,11 +33,17 @@ func init() {
 
 // Collector collects ldpv3 metrics
 type ldpCollector struct {
+	logicalSystem string
+	instance      string
 }
 
-// NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &ldpCollector{}
+// NewCollector creates a new collector (running its commands in the routing instance if set)
+func NewCollector(logicalSystem, instance string) collector.RPCCollector {
+	return &ldpCollector{logicalSystem: logicalSystem, instance: instance}
+}
+
+func (c *ldpCollector) command(cmd string) string {
+	return routinginstance.Command(collector.LogicalSystemCommand(cmd, c.logicalSystem), c.instance)
 }
 
 // Name returns the name of the collector
@@ -63,7 +70,7 @@ func (c *ldpCollector) Collect(client collector.Client, ch chan<- prometheus.Met
 
 func (c *ldpCollector) collectLDPMetrics(client collector.Client, ch chan<- prometheus.Metric, labelValues []string) error {
 	var x = result{}
-	err := client.RunCommandAndParse("show ldp neighbor", &x)
+	err := client.RunCommandAndParse(c.command("show ldp neighbor"), &x)
 	if err != nil {
 		return err
 	}
@@ -76,7 +83,7 @@ func (c *ldpCollector) collectLDPMetrics(client collector.Client, ch chan<- prom
 
 func (c *ldpCollector) collectLDPSessions(client collector.Client, ch chan<- prometheus.Metric, labelValues []string) error {
 	var x = sessionResult{}
-	err := client.RunCommandAndParse("show ldp session", &x)
+	err := client.RunCommandAndParse(c.command("show ldp session"), &x)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: MIT
+
+package ldp
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "ldp",
+		Description: "ldp metrics",
+		Default:     collector.Enabled,
+		Options:     []collector.Option{collector.OptionLogicalSystem, collector.OptionRoutingInstance},
+		New: func(opts collector.Options) collector.RPCCollector {
+			return NewCollector(opts.LogicalSystem, opts.RoutingInstance)
+		},
+	})
+}
@@ -4,6 +4,7 @@ package lldp
 
 import (
 	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -19,11 +20,12 @@ func init() {
 }
 
 type lldpCollector struct {
+	filter *interfacefilter.Filter
 }
 
 // NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &lldpCollector{}
+func NewCollector(filter *interfacefilter.Filter) collector.RPCCollector {
+	return &lldpCollector{filter: filter}
 }
 
 // Name returns the name of the collector
@@ -74,6 +76,10 @@ func (c *lldpCollector) Collect(client collector.Client, ch chan<- prometheus.Me
 				continue
 			}
 
+			if !c.filter.Matches(iface.InterfaceName) {
+				continue
+			}
+
 			// Determine interface state based on whether it has active neighbors
 			state := 0.0
 			if activeInterfaces[iface.InterfaceName] {
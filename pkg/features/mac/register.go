@@ -0,0 +1,15 @@
+// SPDX-License-Identifier: MIT
+
+package mac
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "mac",
+		Description: "MAC address table metrics",
+		New: func(collector.Options) collector.RPCCollector {
+			return NewCollector()
+		},
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
 )
 
 const prefix string = "junos_macsec_"
@@ -59,11 +60,13 @@ func init() {
 }
 
 // macsecCollector collects MACsec metrics
-type macsecCollector struct{}
+type macsecCollector struct {
+	filter *interfacefilter.Filter
+}
 
 // NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &macsecCollector{}
+func NewCollector(filter *interfacefilter.Filter) collector.RPCCollector {
+	return &macsecCollector{filter: filter}
 }
 
 // Name returns the name of the collector
@@ -122,6 +125,10 @@ func (c *macsecCollector) Collect(client collector.Client, ch chan<- prometheus.
 // collectForSessions collects metrics for the sessions
 func (c *macsecCollector) collectForInterfaces(sessions ShowSecMacsecConns, ch chan<- prometheus.Metric, labelValues []string) {
 	for _, mici := range sessions.MacsecConnectionInformation {
+		if !c.filter.Matches(mici.MacsecInterfaceCommonInformation.InterfaceName) {
+			continue
+		}
+
 		labels := append(labelValues,
 			mici.MacsecInterfaceCommonInformation.InterfaceName,
 			mici.MacsecInterfaceCommonInformation.ConnectivityAssociationName)
@@ -144,6 +151,10 @@ func (c *macsecCollector) collectForInterfaces(sessions ShowSecMacsecConns, ch c
 
 func (c *macsecCollector) collectForStats(sessions ShowSecMacsecStats, ch chan<- prometheus.Metric, labelValues []string) {
 	for interfaceCounter := 0; interfaceCounter < (len(sessions.MacsecStatistics.Interfaces)); interfaceCounter++ {
+		if !c.filter.Matches(sessions.MacsecStatistics.Interfaces[interfaceCounter]) {
+			continue
+		}
+
 		labels := append(labelValues,
 			sessions.MacsecStatistics.Interfaces[interfaceCounter])
 		ch <- prometheus.MustNewConstMetric(macsecSecureChannelTXEncryptedPacketsDesc, prometheus.CounterValue, float64(sessions.MacsecStatistics.SecureChannelSent[interfaceCounter].EncryptedPackets), labels...)
@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: MIT
+
+package macsec
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "macsec",
+		Description: "MACSec metrics",
+		Default:     collector.Enabled,
+		Options:     []collector.Option{collector.OptionInterfaceFilter},
+		New: func(opts collector.Options) collector.RPCCollector {
+			return NewCollector(opts.InterfaceFilter)
+		},
+	})
+}
@@ -30,6 +30,7 @@ func init() {
 }
 
 type mplsLSPCollector struct {
+	logicalSystem string
 }
 
 // Name returns the name of the collector
@@ -38,8 +39,8 @@ func (*mplsLSPCollector) Name() string {
 }
 
 // NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &mplsLSPCollector{}
+func NewCollector(logicalSystem string) collector.RPCCollector {
+	return &mplsLSPCollector{logicalSystem: logicalSystem}
 }
 
 // Describe describes the metrics
@@ -50,7 +51,7 @@ func (*mplsLSPCollector) Describe(ch chan<- *prometheus.Desc) {
 // Collect collects metrics from JunOS
 func (c *mplsLSPCollector) Collect(client collector.Client, ch chan<- prometheus.Metric, labelValues []string) error {
 	var x = result{}
-	err := client.RunCommandAndParse("show mpls lsp ingress extensive", &x) //ingress:Display LSPs originating at this router
+	err := client.RunCommandAndParse(collector.LogicalSystemCommand("show mpls lsp ingress extensive", c.logicalSystem), &x) //ingress:Display LSPs originating at this router
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,16 @@
+// SPDX-License-Identifier: MIT
+
+package mplslsp
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "mpls_lsp",
+		Description: "MPLS LSP metrics",
+		Options:     []collector.Option{collector.OptionLogicalSystem},
+		New: func(opts collector.Options) collector.RPCCollector {
+			return NewCollector(opts.LogicalSystem)
+		},
+	})
+}
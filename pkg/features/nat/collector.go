@@ -4,6 +4,7 @@ package nat
 
 import (
 	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -451,11 +452,12 @@ func init() {
 }
 
 type natCollector struct {
+	filter *interfacefilter.Filter
 }
 
 // NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &natCollector{}
+func NewCollector(filter *interfacefilter.Filter) collector.RPCCollector {
+	return &natCollector{filter: filter}
 }
 
 // Name returns the name of the collector
@@ -475,6 +477,9 @@ func (c *natCollector) Collect(client collector.Client, ch chan<- prometheus.Met
 		return err
 	}
 	for _, s := range interfaces {
+		if !c.filter.Matches(s.Interface) {
+			continue
+		}
 		c.collectForInterface(s, ch, labelValues)
 	}
 
@@ -483,6 +488,9 @@ func (c *natCollector) Collect(client collector.Client, ch chan<- prometheus.Met
 		return err
 	}
 	for _, s := range poolinterfaces {
+		if !c.filter.Matches(s.Interface) {
+			continue
+		}
 		c.collectForPoolInterface(s, ch, labelValues)
 	}
 
@@ -491,11 +499,17 @@ func (c *natCollector) Collect(client collector.Client, ch chan<- prometheus.Met
 		return err
 	}
 	for _, s := range pooldetailinterfaces {
+		if !c.filter.Matches(s.Interface) {
+			continue
+		}
 		c.collectForPoolDetailInterface(s, ch, labelValues)
 	}
 
 	servicesetscpuinterfaces, err := c.serviceSetsCPUInterfaces(client, ch, labelValues)
 	for _, s := range servicesetscpuinterfaces {
+		if !c.filter.Matches(s.Interface) {
+			continue
+		}
 		c.collectForServiceSetsCPUInterface(s, ch, labelValues)
 	}
 	if err != nil {
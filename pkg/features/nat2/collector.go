@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -180,11 +181,12 @@ func init() {
 }
 
 type natCollector struct {
+	filter *interfacefilter.Filter
 }
 
 // NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &natCollector{}
+func NewCollector(filter *interfacefilter.Filter) collector.RPCCollector {
+	return &natCollector{filter: filter}
 }
 
 // Name returns the name of the collector
@@ -204,6 +206,9 @@ func (c *natCollector) Collect(client collector.Client, ch chan<- prometheus.Met
 		return err
 	}
 	for _, s := range interfaces {
+		if !c.filter.Matches(s.Interface) {
+			continue
+		}
 		c.collectForInterface(s, ch, labelValues)
 	}
 
@@ -215,6 +220,9 @@ func (c *natCollector) Collect(client collector.Client, ch chan<- prometheus.Met
 
 	servicesetscpuinterfaces, err := c.serviceSetsCPUInterfaces(client, ch, labelValues)
 	for _, s := range servicesetscpuinterfaces {
+		if !c.filter.Matches(s.Interface) {
+			continue
+		}
 		c.collectForServiceSetsCPUInterface(s, ch, labelValues)
 	}
 	if err != nil {
@@ -332,6 +340,10 @@ func (c *natCollector) srcNatPools(client collector.Client, ch chan<- prometheus
 func (c *natCollector) collectForSrcNatPool(s []srcNatPool, ch chan<- prometheus.Metric, labelValues []string) {
 
 	for _, pool := range s {
+		if !c.filter.Matches(pool.Interface) {
+			continue
+		}
+
 		lp := append(labelValues, []string{pool.Interface, pool.ServiceSetName, pool.PoolName, pool.PoolID}...)
 
 		ch <- prometheus.MustNewConstMetric(PortOverloadingFactorDesc, prometheus.GaugeValue, float64(pool.PortOverloadingFactor), lp...)
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/routinginstance"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -32,11 +33,12 @@ func init() {
 // Collector collects OSPFv3 metrics
 type ospfCollector struct {
 	LogicalSystem string
+	instance      string
 }
 
-// NewCollector creates a new collector
-func NewCollector(logicalSystem string) collector.RPCCollector {
-	return &ospfCollector{LogicalSystem: logicalSystem}
+// NewCollector creates a new collector (running its commands in the routing instance if set)
+func NewCollector(logicalSystem, instance string) collector.RPCCollector {
+	return &ospfCollector{LogicalSystem: logicalSystem, instance: instance}
 }
 
 // Name returns the name of the collector
@@ -70,7 +72,7 @@ func (c *ospfCollector) collectOSPFMetrics(client collector.Client, ch chan<- pr
 		cmd.WriteString(" logical-system " + c.LogicalSystem)
 	}
 
-	err := client.RunCommandAndParse(cmd.String(), &x)
+	err := client.RunCommandAndParse(routinginstance.Command(cmd.String(), c.instance), &x)
 	if err != nil {
 		return err
 	}
@@ -100,7 +102,7 @@ func (c *ospfCollector) collectOSPFv3Metrics(client collector.Client, ch chan<-
 		cmd.WriteString(" logical-system " + c.LogicalSystem)
 	}
 
-	err := client.RunCommandAndParse(cmd.String(), &x)
+	err := client.RunCommandAndParse(routinginstance.Command(cmd.String(), c.instance), &x)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: MIT
+
+package ospf
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "ospf",
+		Description: "OSPFv3 metrics",
+		Default:     collector.Enabled,
+		Options:     []collector.Option{collector.OptionLogicalSystem, collector.OptionRoutingInstance},
+		New: func(opts collector.Options) collector.RPCCollector {
+			return NewCollector(opts.LogicalSystem, opts.RoutingInstance)
+		},
+	})
+}
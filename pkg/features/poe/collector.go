@@ -2,6 +2,7 @@ package poe
 
 import (
 	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"strconv"
@@ -28,11 +29,13 @@ func init() {
 	poeClassDesc = prometheus.NewDesc(prefix+"class", "Information about interface PoE class", labels, nil)
 }
 
-type poeCollector struct{}
+type poeCollector struct {
+	filter *interfacefilter.Filter
+}
 
 // NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &poeCollector{}
+func NewCollector(filter *interfacefilter.Filter) collector.RPCCollector {
+	return &poeCollector{filter: filter}
 }
 
 // Name returns the name of the collector
@@ -56,6 +59,9 @@ func (p poeCollector) Collect(client collector.Client, ch chan<- prometheus.Metr
 		return errors.Wrap(err, "failed to run command 'show poe interface'")
 	}
 	for _, i := range result.Poe.InterfaceInformation {
+		if !p.filter.Matches(i.Name) {
+			continue
+		}
 		p.CollectForInterface(i, ch, labelValues)
 	}
 	return nil
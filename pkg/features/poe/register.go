@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: MIT
+
+package poe
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "poe",
+		Description: "PoE metrics",
+		Default:     collector.EnabledByFlag,
+		Options:     []collector.Option{collector.OptionInterfaceFilter},
+		New: func(opts collector.Options) collector.RPCCollector {
+			return NewCollector(opts.InterfaceFilter)
+		},
+	})
+}
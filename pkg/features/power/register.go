@@ -0,0 +1,16 @@
+// SPDX-License-Identifier: MIT
+
+package power
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "power",
+		Description: "power metrics",
+		Default:     collector.EnabledByFlag,
+		New: func(collector.Options) collector.RPCCollector {
+			return NewCollector()
+		},
+	})
+}
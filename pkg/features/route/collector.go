@@ -29,6 +29,7 @@ func init() {
 }
 
 type routeCollector struct {
+	logicalSystem string
 }
 
 // Name returns the name of the collector
@@ -37,8 +38,8 @@ func (*routeCollector) Name() string {
 }
 
 // NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &routeCollector{}
+func NewCollector(logicalSystem string) collector.RPCCollector {
+	return &routeCollector{logicalSystem: logicalSystem}
 }
 
 // Describe describes the metrics
@@ -53,7 +54,7 @@ func (*routeCollector) Describe(ch chan<- *prometheus.Desc) {
 // Collect collects metrics from JunOS
 func (c *routeCollector) Collect(client collector.Client, ch chan<- prometheus.Metric, labelValues []string) error {
 	var x = result{}
-	err := client.RunCommandAndParse("show route summary", &x)
+	err := client.RunCommandAndParse(collector.LogicalSystemCommand("show route summary", c.logicalSystem), &x)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,18 @@
+// SPDX-License-Identifier: MIT
+
+package routingengine
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "routingengine",
+		Feature:     "routing_engine",
+		Flag:        "routingengine.enabled",
+		Description: "Routing Engine metrics",
+		Default:     collector.Enabled,
+		New: func(collector.Options) collector.RPCCollector {
+			return NewCollector()
+		},
+	})
+}
@@ -0,0 +1,15 @@
+// SPDX-License-Identifier: MIT
+
+package rpki
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "rpki",
+		Description: "rpki metrics",
+		New: func(collector.Options) collector.RPCCollector {
+			return NewCollector()
+		},
+	})
+}
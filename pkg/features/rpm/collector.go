@@ -4,6 +4,7 @@ package rpm
 
 import (
 	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -34,11 +35,13 @@ func init() {
 	currRTTSumDesc = prometheus.NewDesc(prefix+"rtt_sum_current", "Statistical sum", l, nil)
 }
 
-type rpmCollector struct{}
+type rpmCollector struct {
+	filter *interfacefilter.Filter
+}
 
 // NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &rpmCollector{}
+func NewCollector(filter *interfacefilter.Filter) collector.RPCCollector {
+	return &rpmCollector{filter: filter}
 }
 
 // Name returns the name of the collector
@@ -78,6 +81,9 @@ func (c *rpmCollector) collect(client collector.Client, ch chan<- prometheus.Met
 	}
 
 	for _, probe := range x.Results.Probes {
+		if !c.filter.Matches(probe.Interface) {
+			continue
+		}
 		c.collectForProbe(probe, ch, labelValues)
 	}
 
@@ -0,0 +1,15 @@
+// SPDX-License-Identifier: MIT
+
+package security
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "security",
+		Description: "security metrics",
+		New: func(collector.Options) collector.RPCCollector {
+			return NewCollector()
+		},
+	})
+}
@@ -0,0 +1,15 @@
+// SPDX-License-Identifier: MIT
+
+package securityike
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "security_ike",
+		Description: "security IKE metrics",
+		New: func(collector.Options) collector.RPCCollector {
+			return NewCollector()
+		},
+	})
+}
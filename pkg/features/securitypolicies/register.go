@@ -0,0 +1,15 @@
+// SPDX-License-Identifier: MIT
+
+package securitypolicies
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "security_policies",
+		Description: "security policy metrics",
+		New: func(collector.Options) collector.RPCCollector {
+			return NewCollector()
+		},
+	})
+}
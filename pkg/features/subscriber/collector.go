@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -20,23 +21,27 @@ func init() {
 	subscriberInfo = prometheus.NewDesc(prefix+"", "Subscriber Detail", l, nil)
 }
 
+type subscriberCollector struct {
+	filter *interfacefilter.Filter
+}
+
 // Name implements collector.RPCCollector.
-func (*subcsribers_information) Name() string {
+func (*subscriberCollector) Name() string {
 	return "Subscriber Detail"
 }
 
 // NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &subcsribers_information{}
+func NewCollector(filter *interfacefilter.Filter) collector.RPCCollector {
+	return &subscriberCollector{filter: filter}
 }
 
 // Describe describes the metrics
-func (*subcsribers_information) Describe(ch chan<- *prometheus.Desc) {
+func (*subscriberCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- subscriberInfo
 }
 
 // Collect collects metrics from JunOS
-func (c *subcsribers_information) Collect(client collector.Client, ch chan<- prometheus.Metric, labelValues []string) error {
+func (c *subscriberCollector) Collect(client collector.Client, ch chan<- prometheus.Metric, labelValues []string) error {
 	var x = subcsribers_information{}
 	err := client.RunCommandAndParse("show subscribers client-type dhcp detail", &x) //TODO: see if client-type dhcp can be left out
 	if err != nil {
@@ -49,6 +54,10 @@ func (c *subcsribers_information) Collect(client collector.Client, ch chan<- pro
 	}
 
 	for _, subscriber := range x.SubscribersInformation.Subscriber {
+		if !c.filter.Matches(subscriber.Interface) {
+			continue
+		}
+
 		underlying_interface, err := findUnderlyingInterface(client, subscriber.UnderlyingInterface, logicalInterfaceMap, 2)
 		if err != nil {
 			fmt.Println(err)
@@ -4,6 +4,7 @@ package system
 
 import (
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"math"
 	"regexp"
@@ -14,6 +15,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/rpc"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -202,10 +204,6 @@ func (c *systemCollector) collectBuffers(client collector.Client, ch chan<- prom
 	r := &buffers{}
 
 	err := client.RunCommandAndParseWithParser("show system buffers", func(b []byte) error {
-		if string(b[:]) == "\nerror: syntax error, expecting <command>: buffers\n" || strings.Contains(string(b[:]), "error: command is not valid on the") {
-			log.Infof("system doesn't support show system buffers command")
-			return nil
-		}
 		err := xml.Unmarshal(b, &r)
 		if err != nil {
 			return err
@@ -258,6 +256,12 @@ func (c *systemCollector) collectBuffers(client collector.Client, ch chan<- prom
 		return nil
 	})
 
+	var unsupportedErr *rpc.UnsupportedCommandError
+	if errors.As(err, &unsupportedErr) {
+		log.Infof("system doesn't support show system buffers command")
+		return nil
+	}
+
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,16 @@
+// SPDX-License-Identifier: MIT
+
+package system
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "system",
+		EnabledBy:   []string{"license"},
+		Description: "system metrics",
+		New: func(collector.Options) collector.RPCCollector {
+			return NewCollector()
+		},
+	})
+}
@@ -0,0 +1,17 @@
+// SPDX-License-Identifier: MIT
+
+package systemstatistics
+
+import "github.com/czerwonk/junos_exporter/pkg/collector"
+
+func init() {
+	collector.Register(&collector.Registration{
+		Key:         "system_statistics",
+		Flag:        "systemstatistics.enabled",
+		Description: "system statistics metrics",
+		Default:     collector.Enabled,
+		New: func(collector.Options) collector.RPCCollector {
+			return NewCollector()
+		},
+	})
+}
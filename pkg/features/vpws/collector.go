@@ -4,6 +4,7 @@ package vpws
 
 import (
 	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -33,6 +34,7 @@ func init() {
 }
 
 type vpwsCollector struct {
+	filter *interfacefilter.Filter
 }
 
 // Name returns the name of the collector
@@ -41,8 +43,8 @@ func (*vpwsCollector) Name() string {
 }
 
 // NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &vpwsCollector{}
+func NewCollector(filter *interfacefilter.Filter) collector.RPCCollector {
+	return &vpwsCollector{filter: filter}
 }
 
 // Describe describes the metrics
@@ -61,6 +63,10 @@ func (c *vpwsCollector) Collect(client collector.Client, ch chan<- prometheus.Me
 
 	for _, vInst := range x.Information.VpwsInstances {
 		for _, vIf := range vInst.Interfaces {
+			if !c.filter.Matches(vIf.Name) {
+				continue
+			}
+
 			l := append(labelValues, vInst.Name, vInst.RD, vIf.Name, vIf.Esi, vIf.Mode, vIf.Role)
 			ch <- prometheus.MustNewConstMetric(vpwsStatus, prometheus.GaugeValue, float64(vpwsStatusMap[vIf.Status]), l...)
 
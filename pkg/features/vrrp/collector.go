@@ -4,6 +4,7 @@ package vrrp
 
 import (
 	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/interfacefilter"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -19,6 +20,7 @@ func init() {
 }
 
 type vrrpCollector struct {
+	filter *interfacefilter.Filter
 }
 
 // Name returns the name of the collector
@@ -27,8 +29,8 @@ func (*vrrpCollector) Name() string {
 }
 
 // NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &vrrpCollector{}
+func NewCollector(filter *interfacefilter.Filter) collector.RPCCollector {
+	return &vrrpCollector{filter: filter}
 }
 
 // Describe describes the metrics
@@ -51,6 +53,10 @@ func (c *vrrpCollector) Collect(client collector.Client, ch chan<- prometheus.Me
 	}
 
 	for _, iface := range x.Information.Interfaces {
+		if !c.filter.Matches(iface.Interface) {
+			continue
+		}
+
 		l := labelValues
 		l = append(l, iface.Interface, iface.Group, iface.LocalInterfaceAddress, iface.VirtualIPAddress)
 		ch <- prometheus.MustNewConstMetric(vrrpState, prometheus.GaugeValue, float64(statusValues[iface.VrrpState]), l...)
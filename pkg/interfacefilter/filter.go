@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: MIT
+
+// Package interfacefilter decides which interfaces metrics are exported for.
+package interfacefilter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Filter selects interfaces by name using include and exclude regular expressions
+type Filter struct {
+	include          []*regexp.Regexp
+	exclude          []*regexp.Regexp
+	skipLogicalUnits bool
+}
+
+// New creates a filter. If include patterns are set, only interfaces matching one of them are selected.
+// Interfaces matching an exclude pattern are never selected.
+func New(include, exclude []string, skipLogicalUnits bool) (*Filter, error) {
+	f := &Filter{
+		skipLogicalUnits: skipLogicalUnits,
+	}
+
+	for _, p := range include {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include pattern %q: %w", p, err)
+		}
+
+		f.include = append(f.include, re)
+	}
+
+	for _, p := range exclude {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %w", p, err)
+		}
+
+		f.exclude = append(f.exclude, re)
+	}
+
+	return f, nil
+}
+
+// Matches returns if metrics should be exported for the interface (a nil filter matches all interfaces)
+func (f *Filter) Matches(name string) bool {
+	if f == nil || name == "" {
+		return true
+	}
+
+	if f.skipLogicalUnits && IsLogicalUnit(name) {
+		return false
+	}
+
+	for _, re := range f.exclude {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+
+	if len(f.include) == 0 {
+		return true
+	}
+
+	for _, re := range f.include {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsLogicalUnit returns if the name is the name of a logical unit (e.g. xe-0/0/0.100)
+func IsLogicalUnit(name string) bool {
+	return strings.Contains(name, ".")
+}
+
+var prefixRegex = regexp.MustCompile(`^\^?([a-zA-Z0-9/:-]+)(\.\*)?$`)
+
+// CommandArgument returns the interface name argument (with wildcard) to narrow commands to the selected interfaces,
+// e.g. "xe-*" for the include pattern "^xe-". An empty string is returned if the interfaces can not be expressed
+// by a single Junos wildcard (commands have to be run for all interfaces then).
+func (f *Filter) CommandArgument() string {
+	if f == nil || len(f.include) != 1 {
+		return ""
+	}
+
+	m := prefixRegex.FindStringSubmatch(f.include[0].String())
+	if m == nil || !strings.HasPrefix(f.include[0].String(), "^") {
+		return ""
+	}
+
+	return m[1] + "*"
+}
+
+// Command appends the interface name argument to cmd if the selected interfaces can be expressed by a wildcard
+func (f *Filter) Command(cmd string) string {
+	arg := f.CommandArgument()
+	if arg == "" {
+		return cmd
+	}
+
+	return cmd + " " + arg
+}
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: MIT
+
+package interfacefilter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatches(t *testing.T) {
+	f, err := New([]string{"^xe-", "^et-"}, []string{`\.32767$`, "^et-0/0/1"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, f.Matches("xe-0/0/0"))
+	assert.True(t, f.Matches("xe-0/0/0.100"))
+	assert.False(t, f.Matches("xe-0/0/0.32767"), "excluded unit")
+	assert.True(t, f.Matches("et-0/0/0"))
+	assert.False(t, f.Matches("et-0/0/1"), "excluded interface")
+	assert.False(t, f.Matches("lt-0/0/0"), "not included")
+}
+
+func TestMatchesSkipLogicalUnits(t *testing.T) {
+	f, err := New(nil, []string{"^(lt|pfh|gr)-", "^(bme|jsrv)"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, f.Matches("xe-0/0/0"))
+	assert.False(t, f.Matches("xe-0/0/0.0"), "logical unit")
+	assert.False(t, f.Matches("pfh-0/0/0"))
+	assert.False(t, f.Matches("bme0"))
+	assert.False(t, f.Matches("jsrv"))
+}
+
+func TestNilFilterMatchesAll(t *testing.T) {
+	var f *Filter
+	assert.True(t, f.Matches("xe-0/0/0.0"))
+	assert.Equal(t, "show interfaces extensive", f.Command("show interfaces extensive"))
+}
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		include  []string
+		expected string
+	}{
+		{include: []string{"^xe-"}, expected: "show interfaces extensive xe-*"},
+		{include: []string{"^xe-0/1/.*"}, expected: "show interfaces extensive xe-0/1/*"},
+		{include: []string{"xe-"}, expected: "show interfaces extensive"},
+		{include: []string{"^(xe|et)-"}, expected: "show interfaces extensive"},
+		{include: []string{"^xe-", "^et-"}, expected: "show interfaces extensive"},
+		{include: nil, expected: "show interfaces extensive"},
+	}
+
+	for _, test := range tests {
+		f, err := New(test.include, nil, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, test.expected, f.Command("show interfaces extensive"), "include %v", test.include)
+	}
+
+	_, err := New([]string{"("}, nil, false)
+	assert.Error(t, err)
+}
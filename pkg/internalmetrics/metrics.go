@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: MIT
+
+// Package internalmetrics provides metrics about the exporter itself (connections, commands, parsing).
+// In contrast to the device metrics, which are collected into a fresh registry on every scrape,
+// these metrics are registered once in Registry for the lifetime of the process.
+package internalmetrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "junos_exporter"
+
+// Reasons for failed connection attempts
+const (
+	ReasonDial      = "dial"
+	ReasonTimeout   = "timeout"
+	ReasonAuth      = "auth"
+	ReasonHostKey   = "host-key"
+	ReasonHandshake = "handshake"
+	ReasonJumpHost  = "jump-host"
+)
+
+var (
+	// Registry contains all internal metrics of the exporter and Go runtime/process metrics
+	Registry = prometheus.NewRegistry()
+
+	// ConnectionAttempts counts SSH connection attempts by target
+	ConnectionAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ssh",
+		Name:      "connection_attempts_total",
+		Help:      "Number of SSH connection attempts",
+	}, []string{"target"})
+
+	// ConnectionFailures counts failed SSH connection attempts by target and reason
+	ConnectionFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ssh",
+		Name:      "connection_failures_total",
+		Help:      "Number of failed SSH connection attempts by reason (dial, timeout, auth, host-key, handshake, jump-host)",
+	}, []string{"target", "reason"})
+
+	// ActiveConnections is the number of currently established SSH connections
+	ActiveConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "ssh",
+		Name:      "active_connections",
+		Help:      "Number of established SSH connections (including jump hosts)",
+	})
+
+	// KeepaliveFailures counts failed keepalive requests by target
+	KeepaliveFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ssh",
+		Name:      "keepalive_failures_total",
+		Help:      "Number of failed SSH keepalive requests",
+	}, []string{"target"})
+
+	// SessionOpenFailures counts SSH sessions which could not be opened by target
+	SessionOpenFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ssh",
+		Name:      "session_open_failures_total",
+		Help:      "Number of SSH sessions (CLI or NETCONF) which could not be opened",
+	}, []string{"target"})
+
+	// CommandDuration observes the latency of commands by collector and transport
+	CommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "command_duration_seconds",
+		Help:      "Duration of commands sent to devices",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60},
+	}, []string{"collector", "transport"})
+
+	// CommandResponseSize observes the size of command responses by collector and transport
+	CommandResponseSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "command_response_size_bytes",
+		Help:      "Size of the responses to commands sent to devices",
+		Buckets:   prometheus.ExponentialBuckets(1024, 4, 8),
+	}, []string{"collector", "transport"})
+
+	// ParseErrors counts responses which could not be parsed by collector
+	ParseErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "parse_errors_total",
+		Help:      "Number of command responses which could not be parsed",
+	}, []string{"collector"})
+
+	// CacheHits counts command responses served from the cache by collector
+	CacheHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "hits_total",
+		Help:      "Number of command responses served from the cache",
+	}, []string{"collector"})
+
+	// CacheMisses counts cacheable commands sent to the device by collector
+	CacheMisses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "misses_total",
+		Help:      "Number of cacheable commands which were not found in the cache and sent to the device",
+	}, []string{"collector"})
+
+	// CoalescedScrapes counts requests served by the collection of a concurrent request for the same target
+	CoalescedScrapes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "coalesced_scrapes_total",
+		Help:      "Number of requests served by the in-flight collection of a concurrent request for the same target",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		ConnectionAttempts,
+		ConnectionFailures,
+		ActiveConnections,
+		KeepaliveFailures,
+		SessionOpenFailures,
+		CommandDuration,
+		CommandResponseSize,
+		ParseErrors,
+		CacheHits,
+		CacheMisses,
+		CoalescedScrapes,
+	)
+}
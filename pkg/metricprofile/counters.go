@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: MIT
+
+package metricprofile
+
+// cumulativeGauges are metrics exported as gauge in v1 while their values are counters on the device
+var cumulativeGauges = map[string]bool{
+	"junos_accounting_inline_creation_failure_count":                            true,
+	"junos_accounting_inline_ipv4_creation_failure_count":                       true,
+	"junos_accounting_inline_ipv6_creation_failure_count":                       true,
+	"junos_bgp_session_flap_count":                                              true,
+	"junos_bgp_session_messages_input_count":                                    true,
+	"junos_bgp_session_messages_output_count":                                   true,
+	"junos_firewall_filter_counter_bytes":                                       true,
+	"junos_firewall_filter_counter_packets":                                     true,
+	"junos_firewall_filter_policer_bytes":                                       true,
+	"junos_firewall_filter_policer_packets":                                     true,
+	"junos_mpls_lsp_path_flapcount":                                             true,
+	"junos_nat_statistics_nat_eim_mapping_alloc_failures":                       true,
+	"junos_nat_statistics_nat_map_allocation_failures":                          true,
+	"junos_nat_statistics_nat_map_free_failures":                                true,
+	"junos_nat_statistics_nat_rule_lookup_failures":                             true,
+	"junos_nat_statistics_nat_session_ext_alloc_failures":                       true,
+	"junos_nat_statistics_nat_session_ext_set_failures":                         true,
+	"junos_nat_statistics_nat_total_bytes_processed":                            true,
+	"junos_nat_statistics_nat_total_pkts_discarded":                             true,
+	"junos_nat_statistics_nat_total_pkts_forwarded":                             true,
+	"junos_nat_statistics_nat_total_pkts_processed":                             true,
+	"junos_nat_statistics_nat_total_pkts_restored":                              true,
+	"junos_nat_statistics_nat_total_pkts_translated":                            true,
+	"junos_nat_statistics_nat_total_session_accepts":                            true,
+	"junos_nat_statistics_nat_total_session_create":                             true,
+	"junos_nat_statistics_nat_total_session_destroy":                            true,
+	"junos_nat_statistics_nat_total_session_discards":                           true,
+	"junos_nat_statistics_nat_total_session_ignores":                            true,
+	"junos_nat_statistics_nat_total_session_interest":                           true,
+	"junos_nat_statistics_nat_total_session_pub_req":                            true,
+	"junos_nat_statistics_nat_total_session_time_event":                         true,
+	"junos_nat_statistics_pool_app_exceed_port_limit_errors":                    true,
+	"junos_nat_statistics_pool_app_port_errors":                                 true,
+	"junos_nat_statistics_pool_block_allocation_errors":                         true,
+	"junos_nat_statistics_pool_blocks_limit_exceeded_errors":                    true,
+	"junos_nat_statistics_pool_mem_alloc_errors":                                true,
+	"junos_nat_statistics_pool_out_of_port_errors":                              true,
+	"junos_nat_statistics_pool_parity_port_errors":                              true,
+	"junos_nat_statistics_pool_preserve_range_errors":                           true,
+	"junos_nat_statistics_total_session_close":                                  true,
+	"junos_nat2_statistics_address_pool_hits":                                   true,
+	"junos_nat2_statistics_nat_map_allocation_failures":                         true,
+	"junos_nat2_statistics_nat_map_free_failures":                               true,
+	"junos_nat2_statistics_nat_rule_lookup_failures":                            true,
+	"junos_nat2_statistics_nat_total_pkts_forwarded":                            true,
+	"junos_nat2_statistics_nat_total_pkts_processed":                            true,
+	"junos_nat2_statistics_nat_total_pkts_translated":                           true,
+	"junos_nat2_statistics_source_pool_eif_flow_limit_exceed_drops":             true,
+	"junos_rpki_session_flap_count":                                             true,
+	"junos_rpm_probe_results_received_total":                                    true,
+	"junos_rpm_probe_results_sent_total":                                        true,
+	"junos_system_jumbo_clusters_denied_count":                                  true,
+	"junos_system_mbuf_and_clusters_denied_count":                               true,
+	"junos_system_mbufs_and_clusters_denied_count":                              true,
+	"junos_system_mbufs_denied_count":                                           true,
+	"junos_system_sfbufs_delayed_count":                                         true,
+	"junos_system_sfbufs_denied_count":                                          true,
+	"junos_systemstatistics_icmp6_errors_not_generated_because_rate_limitation": true,
+	"junos_systemstatistics_icmp_drops_due_to_rate_limit":                       true,
+	"junos_systemstatistics_ipv4_option_packets_dropped_due_to_rate_limit":      true,
+	"junos_systemstatistics_ipv6_option_packets_dropped_due_to_rate_limit":      true,
+	"junos_twamp_probe_results_received_total":                                  true,
+	"junos_twamp_probe_results_sent_total":                                      true,
+}
+
+// counterNames are names of counters in v2 differing from the v1 name with _total suffix
+var counterNames = map[string]string{
+	"junos_bgp_session_flap_count":  "junos_bgp_session_flaps_total",
+	"junos_mpls_lsp_path_flapcount": "junos_mpls_lsp_path_flaps_total",
+	"junos_rpki_session_flap_count": "junos_rpki_session_flaps_total",
+}
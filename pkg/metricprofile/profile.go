@@ -0,0 +1,267 @@
+// SPDX-License-Identifier: MIT
+
+// Package metricprofile converts the metrics emitted by the collectors to the naming and typing of a metric profile.
+//
+// The collectors emit the metrics of the v1 profile (the default until the next major version), where many cumulative
+// values are exported as gauges and counters lack the _total suffix. The v2 profile exports cumulative values as counters
+// with _total suffix and values in base units (e.g. ratios instead of percent, seconds instead of milliseconds).
+package metricprofile
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const (
+	// V1 is the profile of the metrics as emitted by the collectors
+	V1 = "v1"
+
+	// V2 exports cumulative values as counters with _total suffix and values in base units
+	V2 = "v2"
+)
+
+// Validate returns an error if profile is not a known profile
+func Validate(profile string) error {
+	if profile != V1 && profile != V2 {
+		return fmt.Errorf("invalid metric profile %q (expected: %s or %s)", profile, V1, V2)
+	}
+
+	return nil
+}
+
+// unit is a suffix of a metric name to be replaced by the base unit
+type unit struct {
+	suffix     string
+	baseSuffix string
+	divisor    float64
+}
+
+var units = []unit{
+	{suffix: "_percent", baseSuffix: "_ratio", divisor: 100},
+	{suffix: "_percentage", baseSuffix: "_ratio", divisor: 100},
+	{suffix: "_ms", baseSuffix: "_seconds", divisor: 1000},
+	{suffix: "_milliseconds", baseSuffix: "_seconds", divisor: 1000},
+}
+
+// maxCachedDescs limits the number of descriptors conversions are cached for by identity
+const maxCachedDescs = 65536
+
+// conversion describes how metrics of a descriptor are converted
+type conversion struct {
+	name      string
+	help      string
+	counter   bool
+	divisor   float64
+	unchanged bool
+	desc      *prometheus.Desc
+	mu        sync.Mutex
+}
+
+// Converter converts metrics of the v1 profile to the v2 profile
+type Converter struct {
+	conversions map[string]*conversion
+	byDesc      map[*prometheus.Desc]*conversion
+	mu          sync.Mutex
+}
+
+// NewConverter creates a new converter
+func NewConverter() *Converter {
+	return &Converter{
+		conversions: make(map[string]*conversion),
+		byDesc:      make(map[*prometheus.Desc]*conversion),
+	}
+}
+
+// Convert returns the metric in the v2 profile
+func (c *Converter) Convert(m prometheus.Metric) (prometheus.Metric, error) {
+	desc := m.Desc()
+	if conv := c.cachedConversion(desc); conv != nil && conv.unchanged {
+		return m, nil
+	}
+
+	pb := &dto.Metric{}
+	err := m.Write(pb)
+	if err != nil {
+		return nil, err
+	}
+
+	if pb.Histogram != nil || pb.Summary != nil {
+		c.cacheConversion(desc, &conversion{unchanged: true})
+		return m, nil
+	}
+
+	conv, err := c.conversionFor(m, desc, pb.Counter != nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if conv.unchanged {
+		return m, nil
+	}
+
+	var v float64
+	switch {
+	case pb.Counter != nil:
+		v = pb.Counter.GetValue()
+	case pb.Gauge != nil:
+		v = pb.Gauge.GetValue()
+	case pb.Untyped != nil:
+		v = pb.Untyped.GetValue()
+	}
+
+	labelNames := make([]string, len(pb.Label))
+	labelValues := make([]string, len(pb.Label))
+	for i, l := range pb.Label {
+		labelNames[i] = l.GetName()
+		labelValues[i] = l.GetValue()
+	}
+
+	valueType := prometheus.GaugeValue
+	if conv.counter {
+		valueType = prometheus.CounterValue
+	}
+
+	converted, err := prometheus.NewConstMetric(conv.descFor(labelNames), valueType, v/conv.divisor, labelValues...)
+	if err != nil {
+		return nil, err
+	}
+
+	if pb.TimestampMs != nil {
+		return prometheus.NewMetricWithTimestamp(time.UnixMilli(pb.GetTimestampMs()), converted), nil
+	}
+
+	return converted, nil
+}
+
+// cachedConversion returns the conversion of the descriptor if already known by its identity (nil otherwise)
+func (c *Converter) cachedConversion(desc *prometheus.Desc) *conversion {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.byDesc[desc]
+}
+
+func (c *Converter) cacheConversion(desc *prometheus.Desc, conv *conversion) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cacheConversionLocked(desc, conv)
+}
+
+func (c *Converter) cacheConversionLocked(desc *prometheus.Desc, conv *conversion) {
+	if len(c.byDesc) >= maxCachedDescs {
+		// descriptors created on every scrape would let the cache grow without bounds
+		clear(c.byDesc)
+	}
+
+	c.byDesc[desc] = conv
+}
+
+func (c *Converter) conversionFor(m prometheus.Metric, desc *prometheus.Desc, counter bool) (*conversion, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if conv, found := c.byDesc[desc]; found {
+		return conv, nil
+	}
+
+	// descriptors are also compared by their string representation as some descriptors are created on every scrape
+	key := desc.String()
+	if conv, found := c.conversions[key]; found {
+		c.cacheConversionLocked(desc, conv)
+		return conv, nil
+	}
+
+	name, help, err := nameAndHelp(m)
+	if err != nil {
+		return nil, err
+	}
+
+	conv := &conversion{
+		name:    name,
+		help:    help,
+		counter: counter || cumulativeGauges[name],
+		divisor: 1,
+	}
+
+	for _, u := range units {
+		if strings.HasSuffix(conv.name, u.suffix) {
+			conv.name = strings.TrimSuffix(conv.name, u.suffix) + u.baseSuffix
+			conv.divisor = u.divisor
+			break
+		}
+	}
+
+	if conv.counter {
+		conv.name = counterName(conv.name)
+	}
+
+	conv.unchanged = conv.name == name && conv.divisor == 1 && conv.counter == counter
+	c.conversions[key] = conv
+	c.cacheConversionLocked(desc, conv)
+
+	return conv, nil
+}
+
+func (conv *conversion) descFor(labelNames []string) *prometheus.Desc {
+	conv.mu.Lock()
+	defer conv.mu.Unlock()
+
+	// label names of metrics of the same descriptor do not change
+	if conv.desc == nil {
+		conv.desc = prometheus.NewDesc(conv.name, conv.help, labelNames, nil)
+	}
+
+	return conv.desc
+}
+
+// counterName returns the v2 name of a counter (e.g. junos_bgp_session_flap_count -> junos_bgp_session_flaps_total)
+func counterName(name string) string {
+	if n, found := counterNames[name]; found {
+		return n
+	}
+
+	name = strings.TrimSuffix(name, "_count")
+	if strings.HasSuffix(name, "_total") {
+		return name
+	}
+
+	return name + "_total"
+}
+
+// nameAndHelp returns the name and help of the metric as gathered by a registry (the descriptor does not expose them)
+func nameAndHelp(m prometheus.Metric) (name string, help string, err error) {
+	reg := prometheus.NewRegistry()
+	err = reg.Register(singleMetric{m: m})
+	if err != nil {
+		return "", "", err
+	}
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		return "", "", err
+	}
+
+	if len(mfs) != 1 {
+		return "", "", fmt.Errorf("could not gather metric %s", m.Desc())
+	}
+
+	return mfs[0].GetName(), mfs[0].GetHelp(), nil
+}
+
+// singleMetric is an unchecked collector emitting a single metric
+type singleMetric struct {
+	m prometheus.Metric
+}
+
+func (s singleMetric) Describe(ch chan<- *prometheus.Desc) {
+}
+
+func (s singleMetric) Collect(ch chan<- prometheus.Metric) {
+	ch <- s.m
+}
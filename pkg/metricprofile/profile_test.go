@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: MIT
+
+package metricprofile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+type metrics []prometheus.Metric
+
+func (m metrics) Describe(ch chan<- *prometheus.Desc) {
+}
+
+func (m metrics) Collect(ch chan<- prometheus.Metric) {
+	for _, metric := range m {
+		ch <- metric
+	}
+}
+
+func TestConvert(t *testing.T) {
+	flaps := prometheus.NewDesc("junos_bgp_session_flap_count", "Number of session flaps", []string{"target", "peer"}, nil)
+	receiveBytes := prometheus.NewDesc("junos_interface_receive_bytes", "Received data in bytes", []string{"target", "name"}, nil)
+	cpu := prometheus.NewDesc("junos_route_engine_cpu_idle_percent", "CPU idle in percent", []string{"target"}, nil)
+	up := prometheus.NewDesc("junos_up", "Scrape of target was successful", []string{"target"}, nil)
+
+	c := NewConverter()
+	input := []prometheus.Metric{
+		prometheus.MustNewConstMetric(flaps, prometheus.GaugeValue, 3, "router1", "192.0.2.1"),
+		prometheus.MustNewConstMetric(receiveBytes, prometheus.CounterValue, 1024, "router1", "xe-0/0/0"),
+		prometheus.MustNewConstMetric(cpu, prometheus.GaugeValue, 95, "router1"),
+		prometheus.MustNewConstMetric(up, prometheus.GaugeValue, 1, "router1"),
+	}
+
+	converted := make(metrics, 0, len(input))
+	for _, m := range input {
+		cm, err := c.Convert(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		converted = append(converted, cm)
+	}
+
+	assert.Same(t, input[3], converted[3], "unchanged metrics should not be copied")
+
+	expected := `
+# HELP junos_bgp_session_flaps_total Number of session flaps
+# TYPE junos_bgp_session_flaps_total counter
+junos_bgp_session_flaps_total{peer="192.0.2.1",target="router1"} 3
+# HELP junos_interface_receive_bytes_total Received data in bytes
+# TYPE junos_interface_receive_bytes_total counter
+junos_interface_receive_bytes_total{name="xe-0/0/0",target="router1"} 1024
+# HELP junos_route_engine_cpu_idle_ratio CPU idle in percent
+# TYPE junos_route_engine_cpu_idle_ratio gauge
+junos_route_engine_cpu_idle_ratio{target="router1"} 0.95
+# HELP junos_up Scrape of target was successful
+# TYPE junos_up gauge
+junos_up{target="router1"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(converted, strings.NewReader(expected)))
+}
+
+func TestConvertUnchangedWritesOncePerDescriptor(t *testing.T) {
+	up := prometheus.NewDesc("junos_up", "Scrape of target was successful", []string{"target"}, nil)
+
+	c := NewConverter()
+	for _, target := range []string{"router1", "router2", "router3"} {
+		m := &writeCounter{Metric: prometheus.MustNewConstMetric(up, prometheus.GaugeValue, 1, target)}
+		converted, err := c.Convert(m)
+		assert.NoError(t, err)
+		assert.Same(t, m, converted)
+
+		if target != "router1" {
+			assert.Equal(t, 0, m.writes, "conversion known for the descriptor")
+		}
+	}
+}
+
+type writeCounter struct {
+	prometheus.Metric
+	writes int
+}
+
+func (m *writeCounter) Write(out *dto.Metric) error {
+	m.writes++
+	return m.Metric.Write(out)
+}
+
+func TestNameAndHelp(t *testing.T) {
+	desc := prometheus.NewDesc("junos_alarm_count", `Number of "major" alarms`, []string{"target"}, prometheus.Labels{"site": "fra1"})
+
+	name, help, err := nameAndHelp(prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, "router1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "junos_alarm_count", name)
+	assert.Equal(t, `Number of "major" alarms`, help)
+}
+
+func TestCounterName(t *testing.T) {
+	assert.Equal(t, "junos_bgp_session_messages_input_total", counterName("junos_bgp_session_messages_input_count"))
+	assert.Equal(t, "junos_rpm_probe_results_sent_total", counterName("junos_rpm_probe_results_sent_total"))
+	assert.Equal(t, "junos_firewall_filter_counter_bytes_total", counterName("junos_firewall_filter_counter_bytes"))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(V1))
+	assert.NoError(t, Validate(V2))
+	assert.EqualError(t, Validate("v3"), `invalid metric profile "v3" (expected: v1 or v2)`)
+}
@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: MIT
+
+// Package routinginstance provides the routing instance awareness of protocol collectors.
+package routinginstance
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Master is the name of the default routing instance
+const Master = "master"
+
+// Filter is the allow-list of routing instances to export metrics for
+type Filter struct {
+	include []*regexp.Regexp
+}
+
+// New creates a filter. If include patterns are set, only routing instances matching one of them are selected.
+// The master instance is always selected.
+func New(include []string) (*Filter, error) {
+	f := &Filter{}
+
+	for _, p := range include {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include pattern %q: %w", p, err)
+		}
+
+		f.include = append(f.include, re)
+	}
+
+	return f, nil
+}
+
+// Matches returns if metrics should be exported for the routing instance (a nil filter matches all instances)
+func (f *Filter) Matches(name string) bool {
+	if f == nil || len(f.include) == 0 || name == "" || name == Master {
+		return true
+	}
+
+	for _, re := range f.include {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Command returns the command to run cmd in the routing instance (cmd if no instance is set)
+func Command(cmd, instance string) string {
+	if instance == "" {
+		return cmd
+	}
+
+	return cmd + " instance " + instance
+}
+
+// Name returns the name of the routing instance (master if empty)
+func Name(instance string) string {
+	if instance == "" {
+		return Master
+	}
+
+	return instance
+}
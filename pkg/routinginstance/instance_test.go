@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: MIT
+
+package routinginstance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter(t *testing.T) {
+	f, err := New([]string{"^CUST-", "^INTERNET$"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, f.Matches("CUST-A"))
+	assert.True(t, f.Matches("INTERNET"))
+	assert.True(t, f.Matches(Master))
+	assert.True(t, f.Matches(""))
+	assert.False(t, f.Matches("MGMT"))
+	assert.False(t, f.Matches("INTERNET-2"))
+}
+
+func TestFilterWithoutIncludeMatchesAll(t *testing.T) {
+	f, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, f.Matches("CUST-A"))
+
+	var nilFilter *Filter
+	assert.True(t, nilFilter.Matches("CUST-A"))
+}
+
+func TestShouldRejectInvalidPattern(t *testing.T) {
+	_, err := New([]string{"("})
+	assert.ErrorContains(t, err, `invalid include pattern "("`)
+}
+
+func TestCommand(t *testing.T) {
+	assert.Equal(t, "show ospf overview", Command("show ospf overview", ""))
+	assert.Equal(t, "show ospf overview instance CUST-A", Command("show ospf overview", "CUST-A"))
+}
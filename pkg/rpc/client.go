@@ -3,11 +3,16 @@
 package rpc
 
 import (
+	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/czerwonk/junos_exporter/pkg/connector"
+	"github.com/czerwonk/junos_exporter/pkg/internalmetrics"
 )
 
 // Parser parses XML of RPC-Output
@@ -33,12 +38,36 @@ func WithLicenseInformation() ClientOption {
 	}
 }
 
+// WithNetconf sends commands as NETCONF RPCs instead of running them in the CLI
+func WithNetconf() ClientOption {
+	return func(cl *Client) {
+		cl.netconf = true
+	}
+}
+
+// WithUnsupportedCommands remembers commands rejected as not supported by the device in u and skips them on later runs
+func WithUnsupportedCommands(u *UnsupportedCommands) ClientOption {
+	return func(cl *Client) {
+		cl.unsupported = u
+	}
+}
+
+// WithCommandTimeout aborts commands not finished within d
+func WithCommandTimeout(d time.Duration) ClientOption {
+	return func(cl *Client) {
+		cl.commandTimeout = d
+	}
+}
+
 // Client sends commands to JunOS and parses results
 type Client struct {
-	conn      *connector.SSHConnection
-	debug     bool
-	satellite bool
-	license   bool
+	conn           *connector.SSHConnection
+	debug          bool
+	satellite      bool
+	license        bool
+	netconf        bool
+	unsupported    *UnsupportedCommands
+	commandTimeout time.Duration
 }
 
 // NewClient creates a new client to connect to
@@ -54,28 +83,136 @@ func NewClient(ssh *connector.SSHConnection, opts ...ClientOption) *Client {
 
 // RunCommandAndParse runs a command on JunOS and unmarshals the XML result
 func (c *Client) RunCommandAndParse(cmd string, obj interface{}) error {
-	return c.RunCommandAndParseWithParser(cmd, func(b []byte) error {
+	return c.RunCommandAndParseContext(context.Background(), cmd, obj)
+}
+
+// RunCommandAndParseContext runs a command on JunOS and unmarshals the XML result.
+// The command is aborted when the context is done.
+func (c *Client) RunCommandAndParseContext(ctx context.Context, cmd string, obj interface{}) error {
+	return c.RunCommandAndParseWithParserContext(ctx, cmd, func(b []byte) error {
 		return xml.Unmarshal(b, obj)
 	})
 }
 
 // RunCommandAndParseWithParser runs a command on JunOS and unmarshals the XML result using the specified parser function
 func (c *Client) RunCommandAndParseWithParser(cmd string, parser Parser) error {
+	return c.RunCommandAndParseWithParserContext(context.Background(), cmd, parser)
+}
+
+// RunCommandAndParseWithParserContext runs a command on JunOS and unmarshals the XML result using the specified parser function.
+// The command is aborted when the context is done.
+func (c *Client) RunCommandAndParseWithParserContext(ctx context.Context, cmd string, parser Parser) error {
+	host := c.conn.Host()
+
+	if c.unsupported != nil && c.unsupported.Contains(host, cmd) {
+		if c.debug {
+			log.Printf("Skipping command not supported by %s: %s\n", host, cmd)
+		}
+
+		return &UnsupportedCommandError{
+			Host:    host,
+			Version: c.unsupported.version(host),
+			Command: cmd,
+			Err:     ErrCommandSkipped,
+		}
+	}
+
 	if c.debug {
-		log.Printf("Running command on %s: %s\n", c.conn.Host(), cmd)
+		log.Printf("Running command on %s: %s\n", host, cmd)
 	}
 
-	b, err := c.conn.RunCommand(fmt.Sprintf("%s | display xml", cmd))
+	start := time.Now()
+	b, err := c.runCommand(ctx, cmd)
 	if err != nil {
-		return err
+		return &TransportError{Command: cmd, Err: err}
 	}
 
+	// labeled by collector as commands contain names of interfaces, logical systems and routing instances
+	collector := CollectorFromContext(ctx)
+	internalmetrics.CommandDuration.WithLabelValues(collector, c.transport()).Observe(time.Since(start).Seconds())
+	internalmetrics.CommandResponseSize.WithLabelValues(collector, c.transport()).Observe(float64(len(b)))
+
 	if c.debug {
-		log.Printf("Output for %s: %s\n", c.conn.Host(), string(b))
+		log.Printf("Output for %s: %s\n", host, string(b))
+	}
+
+	err = c.checkReply(cmd, b)
+	if err != nil {
+		return err
 	}
 
 	err = parser(b)
-	return err
+	if err != nil {
+		internalmetrics.ParseErrors.WithLabelValues(collector).Inc()
+		return &ParseError{Command: cmd, Err: err}
+	}
+
+	return nil
+}
+
+func (c *Client) runCommand(ctx context.Context, cmd string) ([]byte, error) {
+	if c.commandTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.commandTimeout)
+		defer cancel()
+	}
+
+	if !c.netconf {
+		return c.conn.RunCommandContext(ctx, fmt.Sprintf("%s | display xml", cmd))
+	}
+
+	return c.conn.RunNetconfRPCContext(ctx, commandRPC(cmd))
+}
+
+// checkReply returns the error reported by the device in the reply.
+// Commands rejected as not supported are remembered to be skipped on later runs.
+func (c *Client) checkReply(cmd string, b []byte) error {
+	if c.unsupported != nil {
+		if version := versionFromReply(b); version != "" {
+			c.unsupported.setVersion(c.conn.Host(), version)
+		}
+	}
+
+	err := errorFromReply(b)
+	if err == nil {
+		return nil
+	}
+
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || !rpcErr.isUnsupportedCommand() {
+		return err
+	}
+
+	version := ""
+	if c.unsupported != nil {
+		version = c.unsupported.add(c.conn.Host(), cmd)
+	}
+
+	return &UnsupportedCommandError{
+		Host:    c.conn.Host(),
+		Version: version,
+		Command: cmd,
+		Err:     err,
+	}
+}
+
+// transport returns the name of the transport commands are sent with (used to label internal metrics)
+func (c *Client) transport() string {
+	if c.netconf {
+		return "netconf"
+	}
+
+	return "cli"
+}
+
+// commandRPC wraps a CLI command into the Junos command RPC
+func commandRPC(cmd string) string {
+	b := &strings.Builder{}
+	b.WriteString(`<command format="xml">`)
+	xml.EscapeText(b, []byte(cmd))
+	b.WriteString(`</command>`)
+
+	return b.String()
 }
 
 // Device returns device information for the connected device
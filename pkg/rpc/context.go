@@ -0,0 +1,18 @@
+// SPDX-License-Identifier: MIT
+
+package rpc
+
+import "context"
+
+type collectorContextKey struct{}
+
+// ContextWithCollector returns a context carrying the key of the collector running the commands (used to label internal metrics)
+func ContextWithCollector(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, collectorContextKey{}, name)
+}
+
+// CollectorFromContext returns the key of the collector running the commands or an empty string if not set
+func CollectorFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(collectorContextKey{}).(string)
+	return name
+}
@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: MIT
+
+package rpc
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RPCError is an error reported by the device, either in an rpc-error element of a NETCONF rpc-reply,
+// in an xnm:error element or as error message in the CLI output
+type RPCError struct {
+	Type     string `xml:"error-type"`
+	Tag      string `xml:"error-tag"`
+	Severity string `xml:"error-severity"`
+	Path     string `xml:"error-path"`
+	Message  string `xml:"error-message"`
+}
+
+// Error implements the error interface
+func (e *RPCError) Error() string {
+	msg := strings.TrimSpace(e.Message)
+	if msg == "" {
+		msg = e.Tag
+	}
+
+	if e.Type == "" && e.Tag == "" {
+		return fmt.Sprintf("device error: %s", msg)
+	}
+
+	if e.Path != "" {
+		return fmt.Sprintf("rpc-error (%s/%s) at %s: %s", e.Type, e.Tag, strings.TrimSpace(e.Path), msg)
+	}
+
+	return fmt.Sprintf("rpc-error (%s/%s): %s", e.Type, e.Tag, msg)
+}
+
+// isUnsupportedCommand returns if the device rejected the command as not supported (e.g. by the platform or Junos version)
+func (e *RPCError) isUnsupportedCommand() bool {
+	msg := strings.ToLower(e.Message)
+
+	return strings.Contains(msg, "syntax error") ||
+		strings.Contains(msg, "unknown command") ||
+		strings.Contains(msg, "is not valid on the")
+}
+
+// ErrCommandSkipped is wrapped by the UnsupportedCommandError returned for commands not run again
+// because the device rejected them before (the parser is not called and no metrics are exported)
+var ErrCommandSkipped = errors.New("skipped")
+
+// UnsupportedCommandError is returned when a command is not supported by the device.
+// The command is remembered and skipped on later runs until the retry interval has passed or the Junos version of the device changes.
+type UnsupportedCommandError struct {
+	Host    string
+	Version string
+	Command string
+	Err     error
+}
+
+// Error implements the error interface
+func (e *UnsupportedCommandError) Error() string {
+	version := e.Version
+	if version == "" {
+		version = "unknown version"
+	}
+
+	return fmt.Sprintf("command %q is not supported by %s (Junos %s): %v", e.Command, e.Host, version, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *UnsupportedCommandError) Unwrap() error {
+	return e.Err
+}
+
+// TransportError is returned when a command could not be sent to the device or its output could not be received
+type TransportError struct {
+	Command string
+	Err     error
+}
+
+// Error implements the error interface
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("could not run command %q: %v", e.Command, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// ParseError is returned when the output of a command could not be parsed
+type ParseError struct {
+	Command string
+	Err     error
+}
+
+// Error implements the error interface
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("could not parse output of command %q: %v", e.Command, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// xnmError is an error element in the CLI XML output (e.g. <xnm:error><message>syntax error</message></xnm:error>)
+type xnmError struct {
+	SourceDaemon string `xml:"source-daemon"`
+	Message      string `xml:"message"`
+}
+
+// errorFromReply returns the error reported by the device in the reply or nil if the reply contains data.
+// Errors are reported as rpc-error (NETCONF), xnm:error or plain text (CLI).
+func errorFromReply(b []byte) error {
+	trimmed := bytes.TrimSpace(b)
+	if len(trimmed) > 0 && trimmed[0] != '<' && !bytes.Contains(trimmed, []byte("<rpc-reply")) {
+		return cliErrorFromOutput(trimmed)
+	}
+
+	if !bytes.Contains(b, []byte("rpc-error")) && !bytes.Contains(b, []byte("xnm:error")) {
+		return nil
+	}
+
+	d := xml.NewDecoder(bytes.NewReader(b))
+	for {
+		t, err := d.Token()
+		if err != nil {
+			// malformed replies are reported by the parser
+			return nil
+		}
+
+		se, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case se.Name.Local == "rpc-error":
+			e := &RPCError{}
+			err = d.DecodeElement(e, &se)
+			if err != nil {
+				return nil
+			}
+
+			if strings.TrimSpace(e.Severity) != "warning" {
+				return e
+			}
+		case se.Name.Local == "error" && strings.Contains(se.Name.Space, "xnm"):
+			e := &xnmError{}
+			err = d.DecodeElement(e, &se)
+			if err != nil {
+				return nil
+			}
+
+			return &RPCError{
+				Severity: "error",
+				Message:  strings.TrimSpace(e.Message),
+			}
+		}
+	}
+}
+
+// cliErrorFromOutput returns the error printed by the CLI instead of XML output, e.g.
+//
+//	                                ^
+//	syntax error, expecting <command>.
+func cliErrorFromOutput(b []byte) error {
+	lines := make([]string, 0)
+	for _, l := range strings.Split(string(b), "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" || l == "^" {
+			continue
+		}
+
+		lines = append(lines, l)
+	}
+
+	msg := strings.Join(lines, " ")
+	if !strings.Contains(msg, "error") {
+		return nil
+	}
+
+	return &RPCError{
+		Severity: "error",
+		Message:  msg,
+	}
+}
+
+var versionRegex = regexp.MustCompile(`xml\.juniper\.net/junos/([^/"]+)/junos`)
+
+// versionFromReply returns the Junos version from the namespace of the reply (e.g. xmlns:junos="http://xml.juniper.net/junos/23.4R2-S3.9/junos")
+func versionFromReply(b []byte) string {
+	// the namespace is declared on the root element
+	if len(b) > 1024 {
+		b = b[:1024]
+	}
+
+	m := versionRegex.FindSubmatch(b)
+	if m == nil {
+		return ""
+	}
+
+	return string(m[1])
+}
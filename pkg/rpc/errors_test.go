@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: MIT
+
+package rpc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRPCErrorFromReply(t *testing.T) {
+	reply := `<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" xmlns:junos="http://xml.juniper.net/junos/23.4R2-S3.9/junos" message-id="3">
+<rpc-error>
+<error-type>protocol</error-type>
+<error-tag>operation-failed</error-tag>
+<error-severity>warning</error-severity>
+<error-message>some warning</error-message>
+</rpc-error>
+<rpc-error>
+<error-type>protocol</error-type>
+<error-tag>operation-failed</error-tag>
+<error-severity>error</error-severity>
+<error-message>syntax error, expecting &lt;command&gt;: satellite</error-message>
+</rpc-error>
+</rpc-reply>`
+
+	err := errorFromReply([]byte(reply))
+
+	var rpcErr *RPCError
+	assert.True(t, errors.As(err, &rpcErr), "error should be of type *RPCError")
+	assert.Equal(t, "error", rpcErr.Severity)
+	assert.Equal(t, "syntax error, expecting <command>: satellite", rpcErr.Message)
+}
+
+func TestRPCErrorFromReplyIgnoresWarnings(t *testing.T) {
+	reply := `<rpc-reply message-id="3">
+<rpc-error>
+<error-severity>warning</error-severity>
+<error-message>some warning</error-message>
+</rpc-error>
+<route-information/>
+</rpc-reply>`
+
+	assert.NoError(t, errorFromReply([]byte(reply)))
+}
+
+func TestCommandRPC(t *testing.T) {
+	assert.Equal(t, `<command format="xml">show interfaces &#34;xe-0/0/0&#34; | match &lt;a&gt;</command>`, commandRPC(`show interfaces "xe-0/0/0" | match <a>`))
+}
+
+func TestErrorFromReplyXNMError(t *testing.T) {
+	reply := `<rpc-reply xmlns:junos="http://xml.juniper.net/junos/21.4R3-S5.4/junos">
+<xnm:error xmlns="http://xml.juniper.net/xnm/1.1/xnm" xmlns:xnm="http://xml.juniper.net/xnm/1.1/xnm">
+<source-daemon>
+mgd
+</source-daemon>
+<message>
+syntax error
+</message>
+</xnm:error>
+</rpc-reply>`
+
+	err := errorFromReply([]byte(reply))
+
+	var rpcErr *RPCError
+	assert.True(t, errors.As(err, &rpcErr), "error should be of type *RPCError")
+	assert.Equal(t, "syntax error", rpcErr.Message)
+	assert.True(t, rpcErr.isUnsupportedCommand())
+	assert.Equal(t, "device error: syntax error", err.Error())
+}
+
+func TestErrorFromReplyCLIError(t *testing.T) {
+	output := `
+                                 ^
+syntax error, expecting <command>.
+`
+
+	err := errorFromReply([]byte(output))
+
+	var rpcErr *RPCError
+	assert.True(t, errors.As(err, &rpcErr), "error should be of type *RPCError")
+	assert.Equal(t, "syntax error, expecting <command>.", rpcErr.Message)
+	assert.True(t, rpcErr.isUnsupportedCommand())
+}
+
+func TestErrorFromReplyData(t *testing.T) {
+	assert.NoError(t, errorFromReply([]byte(`<rpc-reply><route-information/></rpc-reply>`)))
+	assert.NoError(t, errorFromReply([]byte("warning: some warning\n<rpc-reply><route-information/></rpc-reply>")))
+	assert.NoError(t, errorFromReply([]byte("")))
+}
+
+func TestVersionFromReply(t *testing.T) {
+	reply := `<rpc-reply xmlns:junos="http://xml.juniper.net/junos/23.4R2-S3.9/junos">
+<route-information/>
+</rpc-reply>`
+
+	assert.Equal(t, "23.4R2-S3.9", versionFromReply([]byte(reply)))
+	assert.Equal(t, "", versionFromReply([]byte("<rpc-reply/>")))
+}
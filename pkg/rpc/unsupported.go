@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: MIT
+
+package rpc
+
+import (
+	"sync"
+	"time"
+)
+
+type unsupportedCommandKey struct {
+	host    string
+	version string
+	command string
+}
+
+// UnsupportedCommands remembers the commands not supported by a device per Junos version.
+// Commands are tried again after the retry interval, as commands containing names (e.g. of interfaces or routing instances)
+// can be rejected because of the name instead of the command not being supported.
+type UnsupportedCommands struct {
+	versions      map[string]string
+	commands      map[unsupportedCommandKey]time.Time
+	retryInterval time.Duration
+	mu            sync.RWMutex
+}
+
+// NewUnsupportedCommands creates an empty set of unsupported commands tried again after retryInterval
+func NewUnsupportedCommands(retryInterval time.Duration) *UnsupportedCommands {
+	return &UnsupportedCommands{
+		versions:      make(map[string]string),
+		commands:      make(map[unsupportedCommandKey]time.Time),
+		retryInterval: retryInterval,
+	}
+}
+
+// Contains returns if the command is not supported by the Junos version last seen on the device and not to be tried again yet
+func (u *UnsupportedCommands) Contains(host, cmd string) bool {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	retryAt, found := u.commands[unsupportedCommandKey{host: host, version: u.versions[host], command: cmd}]
+	return found && time.Now().Before(retryAt)
+}
+
+// version returns the Junos version last seen on the device
+func (u *UnsupportedCommands) version(host string) string {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	return u.versions[host]
+}
+
+// add marks the command as not supported by the Junos version last seen on the device and returns that version
+func (u *UnsupportedCommands) add(host, cmd string) string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	version := u.versions[host]
+	u.commands[unsupportedCommandKey{host: host, version: version, command: cmd}] = time.Now().Add(u.retryInterval)
+
+	return version
+}
+
+// setVersion sets the Junos version running on the device (commands not supported by other versions are tried again)
+func (u *UnsupportedCommands) setVersion(host, version string) {
+	u.mu.RLock()
+	current := u.versions[host]
+	u.mu.RUnlock()
+
+	if current == version {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.versions[host] = version
+}
@@ -0,0 +1,34 @@
+// SPDX-License-Identifier: MIT
+
+package rpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnsupportedCommands(t *testing.T) {
+	u := NewUnsupportedCommands(time.Hour)
+	u.setVersion("qfx1", "21.4R3")
+
+	assert.Equal(t, "21.4R3", u.add("qfx1", "show services nat pool"))
+	assert.True(t, u.Contains("qfx1", "show services nat pool"))
+	assert.False(t, u.Contains("qfx1", "show bgp summary"), "other command")
+	assert.False(t, u.Contains("mx1", "show services nat pool"), "other device")
+
+	u.setVersion("qfx1", "23.4R2")
+	assert.False(t, u.Contains("qfx1", "show services nat pool"), "command should be tried again after upgrade")
+}
+
+func TestUnsupportedCommandsRetry(t *testing.T) {
+	u := NewUnsupportedCommands(50 * time.Millisecond)
+	u.setVersion("mx1", "23.4R2")
+
+	u.add("mx1", "show route instance foo-bar summary")
+	assert.True(t, u.Contains("mx1", "show route instance foo-bar summary"))
+
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, u.Contains("mx1", "show route instance foo-bar summary"), "command should be tried again after the retry interval")
+}
@@ -0,0 +1,195 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/czerwonk/junos_exporter/internal/config"
+	"github.com/czerwonk/junos_exporter/pkg/connector"
+	"github.com/prometheus/client_golang/prometheus"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// pollingJitter is the maximum deviation of the polling interval (as fraction of the interval)
+const pollingJitter = 0.1
+
+var (
+	snapshotAgeDesc     *prometheus.Desc
+	snapshotSuccessDesc *prometheus.Desc
+)
+
+func init() {
+	snapshotAgeDesc = prometheus.NewDesc(prefix+"snapshot_age_seconds", "Time since the metrics of the target were polled", []string{"target"}, nil)
+	snapshotSuccessDesc = prometheus.NewDesc(prefix+"snapshot_success", "Last poll of the target was successful", []string{"target"}, nil)
+}
+
+// snapshot is the result of the last poll of a device
+type snapshot struct {
+	metrics   []prometheus.Metric
+	timestamp time.Time
+	success   bool
+}
+
+// poller polls devices in the background on their own interval, decoupled from HTTP requests
+type poller struct {
+	polled    map[string]bool
+	snapshots map[string]*snapshot
+	mu        sync.RWMutex
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// startPoller starts polling all devices having a polling interval configured
+func startPoller(devices []*connector.Device, cfg *config.Config) *poller {
+	p := &poller{
+		polled:    make(map[string]bool),
+		snapshots: make(map[string]*snapshot),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	for _, d := range devices {
+		interval := devicePollingInterval(cfg, d.Host)
+		if interval <= 0 {
+			continue
+		}
+
+		log.Infof("Polling %s every %s", d.Host, interval)
+		p.polled[d.Host] = true
+
+		p.wg.Add(1)
+		go p.poll(ctx, d, interval)
+	}
+
+	return p
+}
+
+// devicePollingInterval returns the interval to poll the device in (0 if the device is scraped on request)
+func devicePollingInterval(cfg *config.Config, host string) time.Duration {
+	dc := cfg.FindDeviceConfig(host)
+	if dc != nil && dc.PollingInterval > 0 {
+		return dc.PollingInterval
+	}
+
+	if cfg.PollingInterval > 0 {
+		return cfg.PollingInterval
+	}
+
+	return *pollingInterval
+}
+
+func (p *poller) stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *poller) poll(ctx context.Context, device *connector.Device, interval time.Duration) {
+	defer p.wg.Done()
+
+	// spread the first polls of all devices over the interval
+	wait := rand.N(interval)
+
+	for {
+		select {
+		case <-time.After(wait):
+			p.pollDevice(ctx, device, interval)
+		case <-ctx.Done():
+			return
+		}
+
+		jitter := time.Duration((rand.Float64()*2 - 1) * pollingJitter * float64(interval))
+		wait = interval + jitter
+	}
+}
+
+func (p *poller) pollDevice(ctx context.Context, device *connector.Device, interval time.Duration) {
+	configMu.RLock()
+	defer configMu.RUnlock()
+
+	// a poll has to finish before the next one is started
+	ctx, cancel := context.WithTimeout(ctx, interval)
+	defer cancel()
+
+	c := newJunosCollector(ctx, []*connector.Device{device}, "", nil, nil)
+	_, connected := c.clients[device]
+
+	metrics := collectMetrics(c)
+
+	success := connected && ctx.Err() == nil
+	if !success {
+		log.Warnf("Polling %s failed", device.Host)
+	}
+
+	p.store(device.Host, &snapshot{
+		metrics:   metrics,
+		timestamp: time.Now(),
+		success:   success,
+	})
+}
+
+func (p *poller) store(host string, s *snapshot) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	last, found := p.snapshots[host]
+	if !s.success && found {
+		// keep serving the last complete result set, but report the failure
+		last.success = false
+		return
+	}
+
+	p.snapshots[host] = s
+}
+
+// isPolled returns if the device is polled in the background
+func (p *poller) isPolled(host string) bool {
+	if p == nil {
+		return false
+	}
+
+	return p.polled[host]
+}
+
+// snapshotCollector serves the metrics of the last poll of devices
+type snapshotCollector struct {
+	poller  *poller
+	devices []*connector.Device
+}
+
+// Describe implements prometheus.Collector interface.
+// Metrics are not described as they are collected by another collector beforehand (unchecked collector).
+func (c *snapshotCollector) Describe(ch chan<- *prometheus.Desc) {
+}
+
+// Collect implements prometheus.Collector interface
+func (c *snapshotCollector) Collect(ch chan<- prometheus.Metric) {
+	c.poller.mu.RLock()
+	defer c.poller.mu.RUnlock()
+
+	for _, d := range c.devices {
+		s, found := c.poller.snapshots[d.Host]
+		if !found {
+			// first poll did not finish yet
+			ch <- prometheus.MustNewConstMetric(snapshotSuccessDesc, prometheus.GaugeValue, 0, d.Host)
+			continue
+		}
+
+		for _, m := range s.metrics {
+			ch <- m
+		}
+
+		success := 0.0
+		if s.success {
+			success = 1
+		}
+
+		ch <- prometheus.MustNewConstMetric(snapshotSuccessDesc, prometheus.GaugeValue, success, d.Host)
+		ch <- prometheus.MustNewConstMetric(snapshotAgeDesc, prometheus.GaugeValue, time.Since(s.timestamp).Seconds(), d.Host)
+	}
+}
@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/czerwonk/junos_exporter/pkg/connector"
+)
+
+func TestSnapshotCollector(t *testing.T) {
+	p := &poller{
+		polled:    map[string]bool{"router1": true, "router2": true},
+		snapshots: make(map[string]*snapshot),
+	}
+
+	p.store("router1", &snapshot{
+		metrics: []prometheus.Metric{
+			prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 1, "router1"),
+		},
+		timestamp: time.Now(),
+		success:   true,
+	})
+
+	// a failed poll should not replace the last complete result set
+	p.store("router1", &snapshot{
+		metrics: []prometheus.Metric{
+			prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 0, "router1"),
+		},
+		timestamp: time.Now(),
+		success:   false,
+	})
+
+	c := &snapshotCollector{
+		poller: p,
+		devices: []*connector.Device{
+			{Host: "router1"},
+			{Host: "router2"},
+		},
+	}
+
+	expected := `
+# HELP junos_snapshot_success Last poll of the target was successful
+# TYPE junos_snapshot_success gauge
+junos_snapshot_success{target="router1"} 0
+junos_snapshot_success{target="router2"} 0
+# HELP junos_up Scrape of target was successful
+# TYPE junos_up gauge
+junos_up{target="router1"} 1
+`
+	err := testutil.CollectAndCompare(c, strings.NewReader(expected), "junos_up", "junos_snapshot_success")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, testutil.CollectAndCount(c, "junos_snapshot_age_seconds"), "age should only be reported for targets polled before")
+}
+
+func TestPollerIsPolled(t *testing.T) {
+	var p *poller
+	assert.False(t, p.isPolled("router1"), "nothing is polled if polling is not initialized")
+
+	p = &poller{polled: map[string]bool{"router1": true}}
+	assert.True(t, p.isPolled("router1"))
+	assert.False(t, p.isPolled("router2"))
+}
+
+func TestSplitPolledDevices(t *testing.T) {
+	r1 := &connector.Device{Host: "router1"}
+	r2 := &connector.Device{Host: "router2"}
+
+	live, polled := splitPolledDevices([]*connector.Device{r1, r2}, &poller{polled: map[string]bool{"router1": true}})
+	assert.Equal(t, []*connector.Device{r2}, live)
+	assert.Equal(t, []*connector.Device{r1}, polled)
+
+	live, polled = splitPolledDevices([]*connector.Device{r1, r2}, nil)
+	assert.Equal(t, []*connector.Device{r1, r2}, live, "all devices are scraped if polling is not initialized")
+	assert.Empty(t, polled)
+}
@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"slices"
+
+	"github.com/czerwonk/junos_exporter/pkg/collector"
+	"github.com/czerwonk/junos_exporter/pkg/connector"
+	"github.com/czerwonk/junos_exporter/pkg/routinginstance"
+	"github.com/czerwonk/junos_exporter/pkg/rpc"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const routingInstancesCommand = "show route instance"
+
+// routingInstanceTypes are the types of discovered routing instances protocols are expected to run in
+var routingInstanceTypes = []string{"vrf", "virtual-router"}
+
+type routingInstancesResult struct {
+	XMLName     xml.Name `xml:"rpc-reply"`
+	Information struct {
+		Instances []struct {
+			Name string `xml:"instance-name"`
+			Type string `xml:"instance-type"`
+		} `xml:"instance-core"`
+	} `xml:"instance-information"`
+}
+
+// routingInstancesForDevice returns the routing instances to run the collectors supporting routing instances in
+// (configured or discovered on the device, restricted by the allow-list). The master instance is not included.
+func (c *junosCollector) routingInstancesForDevice(ctx context.Context, device *connector.Device, cl *rpc.Client) []string {
+	r := deviceRoutingInstances(cfg, device.Host)
+	if r == nil || !r.FanOut {
+		return nil
+	}
+
+	names := r.Instances
+	if len(names) == 0 {
+		discovered, err := discoverRoutingInstances(ctx, cl, c.collectors.logicalSystem)
+		if err != nil {
+			log.Errorf("Could not discover routing instances of %s: %v", device.Host, err)
+			return nil
+		}
+
+		names = discovered
+	}
+
+	instances := make([]string, 0, len(names))
+	for _, name := range names {
+		if name != routinginstance.Master && r.Filter.Matches(name) {
+			instances = append(instances, name)
+		}
+	}
+
+	return instances
+}
+
+// discoverRoutingInstances returns the names of the routing instances (VRFs and virtual routers) of the device
+func discoverRoutingInstances(ctx context.Context, cl *rpc.Client, logicalSystem string) ([]string, error) {
+	var x routingInstancesResult
+	err := cl.RunCommandAndParseContext(ctx, collector.LogicalSystemCommand(routingInstancesCommand, logicalSystem), &x)
+	if err != nil {
+		return nil, err
+	}
+
+	return routingInstanceNames(&x), nil
+}
+
+func routingInstanceNames(x *routingInstancesResult) []string {
+	names := make([]string, 0, len(x.Information.Instances))
+	for _, i := range x.Information.Instances {
+		if slices.Contains(routingInstanceTypes, i.Type) {
+			names = append(names, i.Name)
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutingInstanceNames(t *testing.T) {
+	body := `<rpc-reply xmlns:junos="http://xml.juniper.net/junos/21.4R3/junos">
+    <instance-information xmlns="http://xml.juniper.net/junos/21.4R3/junos-routing" junos:style="terse">
+        <instance-core>
+            <instance-name>master</instance-name>
+            <instance-type>forwarding</instance-type>
+        </instance-core>
+        <instance-core>
+            <instance-name>__juniper_private1__</instance-name>
+            <instance-type>forwarding</instance-type>
+        </instance-core>
+        <instance-core>
+            <instance-name>CUST-A</instance-name>
+            <instance-type>vrf</instance-type>
+        </instance-core>
+        <instance-core>
+            <instance-name>LAB</instance-name>
+            <instance-type>virtual-router</instance-type>
+        </instance-core>
+        <instance-core>
+            <instance-name>mgmt_junos</instance-name>
+            <instance-type>non-forwarding</instance-type>
+        </instance-core>
+    </instance-information>
+    <cli>
+        <banner></banner>
+    </cli>
+</rpc-reply>`
+
+	var x routingInstancesResult
+	err := xml.Unmarshal([]byte(body), &x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"CUST-A", "LAB"}, routingInstanceNames(&x))
+}
@@ -4,6 +4,7 @@ package main
 
 import (
 	"context"
+	"encoding/xml"
 	"fmt"
 
 	log "github.com/sirupsen/logrus"
@@ -116,7 +117,9 @@ type clientTracingAdapter struct {
 
 // RunCommandAndParse implements RunCommandAndParse of the collector.Client interface
 func (cta *clientTracingAdapter) RunCommandAndParse(cmd string, obj interface{}) error {
-	return cta.cl.RunCommandAndParse(cmd, obj)
+	return cta.RunCommandAndParseWithParser(cmd, func(b []byte) error {
+		return xml.Unmarshal(b, obj)
+	})
 }
 
 // RunCommandAndParseWithParser implements RunCommandAndParseWithParser of the collector.Client interface
@@ -126,7 +129,7 @@ func (cta *clientTracingAdapter) RunCommandAndParseWithParser(cmd string, parser
 	))
 	defer span.End()
 
-	err := cta.cl.RunCommandAndParseWithParser(cmd, parser)
+	err := cta.cl.RunCommandAndParseWithParserContext(cta.ctx, cmd, parser)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())